@@ -0,0 +1,52 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintLayoutCheck reports overlapping sections and segments. Finding any
+// is an error, so that the exit status tells scripts about them.
+func PrintLayoutCheck(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	problems, err := elfreader.CheckLayout(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(problems) == 0 {
+		p.Printf("No overlapping sections or segments found.\n")
+		return nil
+	}
+
+	for _, problem := range problems {
+		p.Printf("Warning: %s\n", problem)
+	}
+	return fmt.Errorf("found %d layout problems", len(problems))
+}
+
+// PrintValidation lists each way the file departs from the ELF
+// specification with its severity. Errors make the run fail; warnings
+// alone do not.
+func PrintValidation(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	problems := elfreader.Validate(file, ehdr, order)
+	if len(problems) == 0 {
+		p.Printf("No problems found.\n")
+		return nil
+	}
+
+	errorCount := 0
+	for _, problem := range problems {
+		severity := problem.Severity
+		if severity == elfreader.SEVERITY_ERROR {
+			errorCount++
+			severity = p.paint(CATEGORY_REMOVED, severity)
+		}
+		p.Printf("%s: %s\n", severity, problem.Message)
+	}
+	if errorCount > 0 {
+		return fmt.Errorf("found %d errors", errorCount)
+	}
+	return nil
+}
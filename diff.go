@@ -0,0 +1,239 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// diffField is one field of a header as --diff compares it
+type diffField struct {
+	name  string
+	value string
+}
+
+// diffInput holds the parsed headers of one side of a --diff comparison
+type diffInput struct {
+	ehdr    *elfreader.Elf64Ehdr
+	phdrs   []elfreader.Elf64Phdr
+	shdrwns []elfreader.Elf64ShdrWithName
+}
+
+// readDiffInput reads the headers of a file compared by --diff
+func readDiffInput(p *Printer, fileName string) (*diffInput, error) {
+	file, err := openInput(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("%s: opening file: %w", fileName, err)
+	}
+	if closer, ok := file.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	elf, err := elfreader.NewFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: reading ELF header: %w", fileName, err)
+	}
+	elf.Warn = p.Warn
+	phdrs, err := elf.ProgramHeaders()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	shdrwns, err := elf.Sections()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return &diffInput{ehdr: elf.Ehdr, phdrs: phdrs, shdrwns: shdrwns}, nil
+}
+
+// headerFields lists the ELF header fields under their readelf labels
+func headerFields(p *Printer, in *diffInput) []diffField {
+	ehdr := in.ehdr
+	return []diffField{
+		{"Class", p.decoded(elfreader.ClassName(ehdr.Ident[elfreader.EI_CLASS]), fmt.Sprint(ehdr.Ident[elfreader.EI_CLASS]))},
+		{"Data", p.decoded(elfreader.DataEncodingName(ehdr.Ident[elfreader.EI_DATA]), fmt.Sprint(ehdr.Ident[elfreader.EI_DATA]))},
+		{"OS/ABI", p.decoded(elfreader.OSABIName(ehdr.Ident[elfreader.EI_OSABI]), fmt.Sprint(ehdr.Ident[elfreader.EI_OSABI]))},
+		{"ABI Version", fmt.Sprint(ehdr.Ident[elfreader.EI_ABIVERSION])},
+		{"Type", p.decoded(elfreader.FileTypeName(ehdr, in.phdrs), fmt.Sprint(ehdr.Type))},
+		{"Machine", p.decoded(elfreader.MachineName(ehdr.Machine), fmt.Sprint(ehdr.Machine))},
+		{"Version", fmt.Sprintf("0x%x", ehdr.Version)},
+		{"Entry point address", fmt.Sprintf("0x%x", ehdr.Entry)},
+		{"Start of program headers", fmt.Sprintf("%d (bytes into file)", ehdr.Phoff)},
+		{"Start of section headers", fmt.Sprintf("%d (bytes into file)", ehdr.Shoff)},
+		{"Flags", fmt.Sprintf("0x%x", ehdr.Flags)},
+		{"Size of this header", fmt.Sprintf("%d (bytes)", ehdr.Ehsize)},
+		{"Size of program headers", fmt.Sprintf("%d (bytes)", ehdr.Phentsize)},
+		{"Number of program headers", fmt.Sprint(ehdr.Phnum)},
+		{"Size of section headers", fmt.Sprintf("%d (bytes)", ehdr.Shentsize)},
+		{"Number of section headers", fmt.Sprint(ehdr.Shnum)},
+		{"Section header string table index", fmt.Sprint(ehdr.Shstrndx)},
+	}
+}
+
+// sectionFields lists the fields of a section header
+func sectionFields(p *Printer, shdrwn elfreader.Elf64ShdrWithName) []diffField {
+	return []diffField{
+		{"Type", p.decoded(elfreader.SectionTypeName(shdrwn.Type), fmt.Sprint(shdrwn.Type))},
+		{"Flags", p.decoded(elfreader.SectionFlagsString(shdrwn.Flags), fmt.Sprintf("0x%x", shdrwn.Flags))},
+		{"Address", fmt.Sprintf("0x%x", shdrwn.Addr)},
+		{"Offset", fmt.Sprintf("0x%x", shdrwn.Offset)},
+		{"Size", fmt.Sprintf("0x%x", shdrwn.Size)},
+		{"Link", fmt.Sprint(shdrwn.Link)},
+		{"Info", fmt.Sprint(shdrwn.Info)},
+		{"Alignment", fmt.Sprint(shdrwn.Addralign)},
+		{"Entry size", fmt.Sprintf("0x%x", shdrwn.Entsize)},
+	}
+}
+
+// segmentFields lists the fields of a program header but its type, which
+// segments are matched by
+func segmentFields(phdr elfreader.Elf64Phdr) []diffField {
+	return []diffField{
+		{"Flags", elfreader.PhdrFlagsString(phdr.Flags)},
+		{"Offset", fmt.Sprintf("0x%x", phdr.Offset)},
+		{"VirtAddr", fmt.Sprintf("0x%x", phdr.Vaddr)},
+		{"PhysAddr", fmt.Sprintf("0x%x", phdr.Paddr)},
+		{"FileSiz", fmt.Sprintf("0x%x", phdr.Filesz)},
+		{"MemSiz", fmt.Sprintf("0x%x", phdr.Memsz)},
+		{"Align", fmt.Sprintf("0x%x", phdr.Align)},
+	}
+}
+
+// diffKeys names each entry so that the entries of two files can be
+// matched: repeated names are told apart by their occurrence, as in
+// "LOAD #2"
+func diffKeys(names []string) []string {
+	seen := make(map[string]int)
+	keys := make([]string, len(names))
+	for i, name := range names {
+		seen[name]++
+		keys[i] = name
+		if seen[name] > 1 {
+			keys[i] = fmt.Sprintf("%s #%d", name, seen[name])
+		}
+	}
+	return keys
+}
+
+// printRemoved and printAdded print one line of a diff with its marker
+func (p *Printer) printRemoved(format string, args ...interface{}) {
+	p.Printf("%s\n", p.paint(CATEGORY_REMOVED, "-"+fmt.Sprintf(format, args...)))
+}
+
+func (p *Printer) printAdded(format string, args ...interface{}) {
+	p.Printf("%s\n", p.paint(CATEGORY_ADDED, "+"+fmt.Sprintf(format, args...)))
+}
+
+// printFieldDiff prints the fields whose values differ under heading,
+// and reports whether there were any
+func printFieldDiff(p *Printer, heading string, a, b []diffField) bool {
+	width := 0
+	for _, field := range a {
+		if len(field.name) > width {
+			width = len(field.name)
+		}
+	}
+
+	changed := false
+	for i := range a {
+		if a[i].value == b[i].value {
+			continue
+		}
+		if !changed {
+			p.Printf(" %s\n", heading)
+			changed = true
+		}
+		p.printRemoved("  %-*s %s", width+1, a[i].name+":", a[i].value)
+		p.printAdded("  %-*s %s", width+1, b[i].name+":", b[i].value)
+	}
+	return changed
+}
+
+// PrintDiff compares the ELF header, section headers and program headers
+// of two files. Sections are matched by name and segments by type.
+func PrintDiff(p *Printer, fileName1, fileName2 string) error {
+	a, err := readDiffInput(p, fileName1)
+	if err != nil {
+		return err
+	}
+	b, err := readDiffInput(p, fileName2)
+	if err != nil {
+		return err
+	}
+
+	p.Printf("%s\n", p.paint(CATEGORY_REMOVED, "--- "+fileName1))
+	p.Printf("%s\n", p.paint(CATEGORY_ADDED, "+++ "+fileName2))
+	changed := printFieldDiff(p, "ELF Header:", headerFields(p, a), headerFields(p, b))
+
+	// Sections, skipping the null section at index 0
+	sectionKeys := func(in *diffInput) ([]string, map[string]elfreader.Elf64ShdrWithName) {
+		var names []string
+		for i := 1; i < len(in.shdrwns); i++ {
+			names = append(names, in.shdrwns[i].Name)
+		}
+		keys := diffKeys(names)
+		byKey := make(map[string]elfreader.Elf64ShdrWithName)
+		for i, key := range keys {
+			byKey[key] = in.shdrwns[i+1]
+		}
+		return keys, byKey
+	}
+	keysA, sectionsA := sectionKeys(a)
+	keysB, sectionsB := sectionKeys(b)
+	for _, key := range keysA {
+		shdrwn, ok := sectionsB[key]
+		if !ok {
+			p.printRemoved("Section %s (size 0x%x)", key, sectionsA[key].Size)
+			changed = true
+			continue
+		}
+		heading := "Section " + p.colorSection(key) + ":"
+		if printFieldDiff(p, heading, sectionFields(p, sectionsA[key]), sectionFields(p, shdrwn)) {
+			changed = true
+		}
+	}
+	for _, key := range keysB {
+		if _, ok := sectionsA[key]; !ok {
+			p.printAdded("Section %s (size 0x%x)", key, sectionsB[key].Size)
+			changed = true
+		}
+	}
+
+	segmentKeys := func(in *diffInput) ([]string, map[string]elfreader.Elf64Phdr) {
+		names := make([]string, len(in.phdrs))
+		for i, phdr := range in.phdrs {
+			names[i] = elfreader.PhdrTypeName(phdr.Type)
+		}
+		keys := diffKeys(names)
+		byKey := make(map[string]elfreader.Elf64Phdr)
+		for i, key := range keys {
+			byKey[key] = in.phdrs[i]
+		}
+		return keys, byKey
+	}
+	keysA, segmentsA := segmentKeys(a)
+	keysB, segmentsB := segmentKeys(b)
+	for _, key := range keysA {
+		phdr, ok := segmentsB[key]
+		if !ok {
+			p.printRemoved("Segment %s (at 0x%x)", key, segmentsA[key].Vaddr)
+			changed = true
+			continue
+		}
+		heading := "Segment " + p.colorProgram(key) + ":"
+		if printFieldDiff(p, heading, segmentFields(segmentsA[key]), segmentFields(phdr)) {
+			changed = true
+		}
+	}
+	for _, key := range keysB {
+		if _, ok := segmentsA[key]; !ok {
+			p.printAdded("Segment %s (at 0x%x)", key, segmentsB[key].Vaddr)
+			changed = true
+		}
+	}
+
+	if !changed {
+		p.Printf("No structural differences found.\n")
+	}
+	return nil
+}
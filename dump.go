@@ -0,0 +1,160 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"color-readelf/elfreader"
+)
+
+// isPrintable reports whether b is a printable ASCII character
+func isPrintable(b byte) bool {
+	return b >= 0x20 && b < 0x7f
+}
+
+// printCompression notes that a dump shows the decompressed contents of a
+// compressed section
+func printCompression(p *Printer, chdr *elfreader.Elf64Chdr) {
+	if chdr != nil {
+		p.Printf("  [%s compressed section, %d bytes uncompressed]\n", elfreader.CompressionTypeName(chdr.Type), chdr.Size)
+	}
+}
+
+// PrintStringDump displays the printable strings of a section along with
+// their offsets, like readelf -p
+func PrintStringDump(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, name string) error {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	shdrwn, ok := elfreader.FindSection(shdrwns, name)
+	if !ok {
+		return fmt.Errorf("section '%s' was not dumped because it does not exist", name)
+	}
+	if shdrwn.Type == elfreader.SHT_NOBITS {
+		p.Printf("Section '%s' has no data to dump.\n", p.colorSection(shdrwn.Name))
+		return nil
+	}
+	data, chdr, err := elfreader.ReadSectionContents(file, ehdr, order, shdrwn)
+	if err != nil {
+		return err
+	}
+
+	p.Printf("String dump of section '%s':\n", p.colorSection(shdrwn.Name))
+	printCompression(p, chdr)
+	found := false
+	for start := 0; start < len(data); {
+		if !isPrintable(data[start]) {
+			start++
+			continue
+		}
+		end := start
+		for end < len(data) && isPrintable(data[end]) {
+			end++
+		}
+		p.Printf("  [%s]  %s\n", p.colorAddr("%6x", start), data[start:end])
+		found = true
+		start = end
+	}
+	if !found {
+		p.Printf("  No strings found in this section.\n")
+	}
+	return nil
+}
+
+// PrintHexDump displays the contents of a section as 16 bytes of hex and
+// ASCII per line, like readelf -x
+func PrintHexDump(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, name string) error {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	shdrwn, ok := elfreader.FindSection(shdrwns, name)
+	if !ok {
+		return fmt.Errorf("section '%s' was not dumped because it does not exist", name)
+	}
+	if shdrwn.Type == elfreader.SHT_NOBITS {
+		p.Printf("Section '%s' occupies no space in the file; there is nothing to dump.\n", p.colorSection(shdrwn.Name))
+		return nil
+	}
+	data, chdr, err := elfreader.ReadSectionContents(file, ehdr, order, shdrwn)
+	if err != nil {
+		return err
+	}
+
+	p.Printf("Hex dump of section '%s':\n", p.colorSection(shdrwn.Name))
+	printCompression(p, chdr)
+	for offset := 0; offset < len(data); offset += 16 {
+		end := offset + 16
+		if end > len(data) {
+			end = len(data)
+		}
+		line := data[offset:end]
+
+		hex := ""
+		for i := 0; i < 16; i++ {
+			if i < len(line) {
+				hex += fmt.Sprintf("%02x", line[i])
+			} else {
+				hex += "  "
+			}
+			if i%4 == 3 {
+				hex += " "
+			}
+		}
+
+		ascii := make([]byte, len(line))
+		for i, b := range line {
+			if isPrintable(b) {
+				ascii[i] = b
+			} else {
+				ascii[i] = '.'
+			}
+		}
+
+		p.Printf("  %s %s%s\n", p.colorAddr("0x%08x", offset), hex, ascii)
+	}
+	return nil
+}
+
+// ExtractSection writes the raw file contents of a section to w, like
+// objcopy -O binary --only-section. Compressed sections are written as
+// they are stored.
+func ExtractSection(w io.Writer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, name string) error {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	shdrwn, ok := elfreader.FindSection(shdrwns, name)
+	if !ok {
+		return fmt.Errorf("section '%s' was not extracted because it does not exist", name)
+	}
+	if shdrwn.Type == elfreader.SHT_NOBITS {
+		return fmt.Errorf("section '%s' occupies no space in the file; there is nothing to extract", name)
+	}
+	data, err := elfreader.ReadSectionData(file, shdrwn)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// extractSection writes a section extracted with --extract to the file
+// named by --out, or to standard output when there is none
+func extractSection(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, name, outPath string) error {
+	if outPath == "" {
+		return ExtractSection(p.Out, file, ehdr, order, name)
+	}
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	err = ExtractSection(out, file, ehdr, order, name)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintDynamic displays the dynamic array like readelf -d
+func PrintDynamic(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	dynamic, err := elfreader.ReadDynamic(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if dynamic == nil {
+		p.Printf("There is no dynamic section in this file.\n")
+		return nil
+	}
+
+	width := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		width = 8
+	}
+
+	p.Printf("%s at offset %s contains %d entries:\n", p.colorSection("Dynamic section"), p.colorAddr("0x%x", dynamic.Offset), len(dynamic.Entries))
+	p.Printf("  %-*s Type                 Name/Value\n", width+2, "Tag")
+	for _, dyn := range dynamic.Entries {
+		var value string
+		switch dyn.Tag {
+		case elfreader.DT_NEEDED:
+			value = fmt.Sprintf("Shared library: [%s]", elfreader.GetString(dynamic.Strtab, uint32(dyn.Val)))
+		case elfreader.DT_SONAME:
+			value = fmt.Sprintf("Library soname: [%s]", elfreader.GetString(dynamic.Strtab, uint32(dyn.Val)))
+		case elfreader.DT_RPATH:
+			value = fmt.Sprintf("Library rpath: [%s]", elfreader.GetString(dynamic.Strtab, uint32(dyn.Val)))
+		case elfreader.DT_RUNPATH:
+			value = fmt.Sprintf("Library runpath: [%s]", elfreader.GetString(dynamic.Strtab, uint32(dyn.Val)))
+		default:
+			value = p.colorAddr("0x%x", dyn.Val)
+		}
+		p.Printf("  %s %-20s %s\n", p.colorAddr("0x%0*x", width, uint64(dyn.Tag)), "("+elfreader.DynamicTagName(dyn.Tag)+")", value)
+	}
+	return nil
+}
+
+// PrintDependencies lists the needed shared libraries one per line,
+// followed by the library search paths when the file sets any
+func PrintDependencies(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	deps, err := elfreader.ReadDependencies(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if deps == nil {
+		p.Printf("There is no dynamic section in this file; it is statically linked.\n")
+		return nil
+	}
+
+	for _, needed := range deps.Needed {
+		p.Printf("%s\n", needed)
+	}
+	if deps.Rpath != "" {
+		p.Printf("RPATH: %s\n", deps.Rpath)
+	}
+	if deps.Runpath != "" {
+		p.Printf("RUNPATH: %s\n", deps.Runpath)
+	}
+	return nil
+}
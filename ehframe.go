@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintEHFrameHdr displays the decoded .eh_frame_hdr along with its table
+// of FDE initial locations
+func PrintEHFrameHdr(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	hdr, err := elfreader.ReadEHFrameHdr(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if hdr == nil {
+		p.Printf("There is no eh_frame_hdr in this file.\n")
+		return nil
+	}
+
+	width := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		width = 8
+	}
+
+	p.Printf("eh_frame_hdr at offset %s (address %s):\n", p.colorAddr("0x%x", hdr.Offset), p.colorAddr("0x%x", hdr.Vaddr))
+	p.Printf("  Version:               %d\n", hdr.Version)
+	p.Printf("  eh_frame_ptr encoding: %s (%s)\n", p.colorAddr("0x%02x", hdr.EHFramePtrEnc), elfreader.EHPointerEncodingName(hdr.EHFramePtrEnc))
+	p.Printf("  fde_count encoding:    %s (%s)\n", p.colorAddr("0x%02x", hdr.FDECountEnc), elfreader.EHPointerEncodingName(hdr.FDECountEnc))
+	p.Printf("  Table encoding:        %s (%s)\n", p.colorAddr("0x%02x", hdr.TableEnc), elfreader.EHPointerEncodingName(hdr.TableEnc))
+	p.Printf("  eh_frame pointer:      %s\n", p.colorAddr("0x%x", hdr.EHFramePtr))
+	p.Printf("  FDE count:             %d\n", hdr.FDECount)
+	if len(hdr.Table) == 0 {
+		return nil
+	}
+
+	p.Printf("\n  %-*s  %s\n", width+2, "Initial location", "FDE address")
+	for _, entry := range hdr.Table {
+		p.Printf("  %s  %s\n", p.colorAddr("0x%0*x", width, entry.InitialLocation), p.colorAddr("0x%0*x", width, entry.Address))
+	}
+	return nil
+}
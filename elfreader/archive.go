@@ -0,0 +1,120 @@
+package elfreader
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ARCHIVE_MAGIC starts every static library (.a) archive
+const ARCHIVE_MAGIC = "!<arch>\n"
+
+// archiveHeaderSize is the size of the header before each archive member
+const archiveHeaderSize = 60
+
+// ArchiveMember is one file stored in an archive. The member's contents can
+// be read through Reader.
+type ArchiveMember struct {
+	Name   string
+	Offset int64
+	Size   int64
+	Reader *io.SectionReader
+}
+
+// IsArchive reports whether the file starts with the ar archive magic
+func IsArchive(file io.ReaderAt) bool {
+	magic := make([]byte, len(ARCHIVE_MAGIC))
+	n, _ := file.ReadAt(magic, 0)
+	return n == len(magic) && string(magic) == ARCHIVE_MAGIC
+}
+
+// ReadArchive lists the members of an ar archive, leaving out the symbol
+// table and long name table. Long names are resolved in both the GNU form,
+// "/n" giving an offset into the "//" member, and the BSD form, "#1/n"
+// giving the length of a name stored before the member's contents.
+func ReadArchive(file io.ReaderAt) ([]ArchiveMember, error) {
+	if !IsArchive(file) {
+		return nil, fmt.Errorf("not an ar archive")
+	}
+
+	var members []ArchiveMember
+	var longNames []byte
+	offset := int64(len(ARCHIVE_MAGIC))
+	for {
+		var hdr [archiveHeaderSize]byte
+		n, err := file.ReadAt(hdr[:], offset)
+		if n == 0 && err == io.EOF {
+			return members, nil
+		}
+		if n < len(hdr) {
+			return nil, fmt.Errorf("reading archive member header at offset 0x%x: %w", offset, io.ErrUnexpectedEOF)
+		}
+		if string(hdr[58:60]) != "`\n" {
+			return nil, fmt.Errorf("bad archive member header at offset 0x%x", offset)
+		}
+		size, err := strconv.ParseInt(strings.TrimSpace(string(hdr[48:58])), 10, 64)
+		if err != nil || size < 0 {
+			return nil, fmt.Errorf("bad archive member size %q at offset 0x%x", strings.TrimSpace(string(hdr[48:58])), offset)
+		}
+
+		name := strings.TrimRight(string(hdr[0:16]), " ")
+		data := offset + archiveHeaderSize
+		past, err := pastEnd(file, uint64(data), uint64(size))
+		if err != nil {
+			return nil, err
+		}
+		if past {
+			return nil, fmt.Errorf("archive member %q at offset 0x%x extends past the end of the file", name, offset)
+		}
+		// Members start on even offsets
+		offset = data + size + size%2
+
+		switch {
+		case name == "/" || name == "/SYM64/" || name == "__.SYMDEF" || name == "__.SYMDEF SORTED":
+			continue
+		case name == "//":
+			longNames, err = ReadBytes(file, uint64(data), uint64(size))
+			if err != nil {
+				return nil, fmt.Errorf("reading archive long name table: %w", err)
+			}
+			continue
+		case strings.HasPrefix(name, "#1/"):
+			length, err := strconv.ParseInt(name[3:], 10, 64)
+			if err != nil || length < 0 || length > size {
+				return nil, fmt.Errorf("bad archive member name %q at offset 0x%x", name, data-archiveHeaderSize)
+			}
+			nameData, err := ReadBytes(file, uint64(data), uint64(length))
+			if err != nil {
+				return nil, err
+			}
+			name = string(bytes.TrimRight(nameData, "\x00"))
+			data += length
+			size -= length
+			// The BSD symbol tables are stored under long names
+			if name == "__.SYMDEF" || name == "__.SYMDEF SORTED" {
+				continue
+			}
+		case strings.HasPrefix(name, "/"):
+			index, err := strconv.ParseUint(name[1:], 10, 64)
+			if err != nil || index >= uint64(len(longNames)) {
+				return nil, fmt.Errorf("bad archive member name %q at offset 0x%x", name, data-archiveHeaderSize)
+			}
+			name = string(longNames[index:])
+			if end := strings.Index(name, "/\n"); end >= 0 {
+				name = name[:end]
+			}
+		default:
+			// GNU ends short names with a slash
+			name = strings.TrimSuffix(name, "/")
+		}
+
+		members = append(members, ArchiveMember{
+			Name:   name,
+			Offset: data,
+			Size:   size,
+			Reader: io.NewSectionReader(file, data, size),
+		})
+	}
+}
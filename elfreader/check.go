@@ -0,0 +1,104 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// fileRange is the half-open range of file offsets [start, end)
+type fileRange struct {
+	start, end uint64
+}
+
+// newFileRange returns the range of size bytes at offset. A range running
+// past the largest offset is cut short there rather than wrapping around.
+func newFileRange(offset, size uint64) fileRange {
+	end := offset + size
+	if end < offset {
+		end = math.MaxUint64
+	}
+	return fileRange{offset, end}
+}
+
+func (r fileRange) overlaps(o fileRange) bool {
+	return r.start < o.end && o.start < r.end
+}
+
+func (r fileRange) contains(o fileRange) bool {
+	return r.start <= o.start && o.end <= r.end
+}
+
+// sectionRange returns the file range of a section, or false for sections
+// that occupy no file space
+func sectionRange(shdrwn Elf64ShdrWithName) (fileRange, bool) {
+	if shdrwn.Type == SHT_NULL || shdrwn.Type == SHT_NOBITS || shdrwn.Size == 0 {
+		return fileRange{}, false
+	}
+	return newFileRange(shdrwn.Offset, shdrwn.Size), true
+}
+
+// sectionOverlaps describes each pair of sections that hold the same bytes
+// of the file
+func sectionOverlaps(shdrwns []Elf64ShdrWithName) []string {
+	var problems []string
+	for i, a := range shdrwns {
+		ra, ok := sectionRange(a)
+		if !ok {
+			continue
+		}
+		for _, b := range shdrwns[i+1:] {
+			if rb, ok := sectionRange(b); ok && ra.overlaps(rb) {
+				problems = append(problems, fmt.Sprintf("sections [%d] %s (0x%x-0x%x) and [%d] %s (0x%x-0x%x) overlap in the file",
+					a.Index, a.Name, ra.start, ra.end, b.Index, b.Name, rb.start, rb.end))
+			}
+		}
+	}
+	return problems
+}
+
+// CheckLayout looks for file ranges that should not overlap: two sections
+// holding the same bytes, two PT_LOAD segments loading the same bytes, and
+// allocated sections that are only partly inside a PT_LOAD segment. Each
+// problem is described by one message.
+func CheckLayout(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]string, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	problems := sectionOverlaps(shdrwns)
+	for i, a := range phdrs {
+		if a.Type != PT_LOAD || a.Filesz == 0 {
+			continue
+		}
+		ra := newFileRange(a.Offset, a.Filesz)
+		for j := i + 1; j < len(phdrs); j++ {
+			b := phdrs[j]
+			if b.Type != PT_LOAD || b.Filesz == 0 {
+				continue
+			}
+			if rb := newFileRange(b.Offset, b.Filesz); ra.overlaps(rb) {
+				problems = append(problems, fmt.Sprintf("LOAD segments %d (0x%x-0x%x) and %d (0x%x-0x%x) overlap in the file",
+					i, ra.start, ra.end, j, rb.start, rb.end))
+			}
+		}
+
+		for _, shdrwn := range shdrwns {
+			rs, ok := sectionRange(shdrwn)
+			if !ok || shdrwn.Flags&SHF_ALLOC == 0 {
+				continue
+			}
+			if ra.overlaps(rs) && !ra.contains(rs) {
+				problems = append(problems, fmt.Sprintf("section [%d] %s (0x%x-0x%x) straddles the boundary of LOAD segment %d (0x%x-0x%x)",
+					shdrwn.Index, shdrwn.Name, rs.start, rs.end, i, ra.start, ra.end))
+			}
+		}
+	}
+	return problems, nil
+}
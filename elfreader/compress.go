@@ -0,0 +1,124 @@
+package elfreader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Compression types of SHF_COMPRESSED sections
+const (
+	ELFCOMPRESS_ZLIB = 1
+	ELFCOMPRESS_ZSTD = 2
+)
+
+// Compression headers that lead the data of SHF_COMPRESSED sections
+type Elf64Chdr struct {
+	Type      uint32
+	Reserved  uint32
+	Size      uint64
+	Addralign uint64
+}
+
+type Elf32Chdr struct {
+	Type      uint32
+	Size      uint32
+	Addralign uint32
+}
+
+// CompressionTypeName returns the name of a ch_type value
+func CompressionTypeName(t uint32) string {
+	switch t {
+	case ELFCOMPRESS_ZLIB:
+		return "ZLIB"
+	case ELFCOMPRESS_ZSTD:
+		return "ZSTD"
+	}
+	return fmt.Sprintf("<unknown: %d>", t)
+}
+
+// readCompressionHeader decodes the compression header at the start of
+// data, widening the 32-bit layout, and returns it with its size
+func readCompressionHeader(data []byte, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Chdr, int, error) {
+	r := bytes.NewReader(data)
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		var chdr32 Elf32Chdr
+		if err := readStruct(r, order, &chdr32); err != nil {
+			return Elf64Chdr{}, 0, err
+		}
+		chdr := Elf64Chdr{Type: chdr32.Type, Size: uint64(chdr32.Size), Addralign: uint64(chdr32.Addralign)}
+		return chdr, binary.Size(chdr32), nil
+	}
+	var chdr Elf64Chdr
+	if err := readStruct(r, order, &chdr); err != nil {
+		return Elf64Chdr{}, 0, err
+	}
+	return chdr, binary.Size(chdr), nil
+}
+
+// decompressor returns a reader of the data decompressed with the given
+// ch_type, and a function that releases it
+func decompressor(typ uint32, data []byte) (io.Reader, func(), error) {
+	switch typ {
+	case ELFCOMPRESS_ZLIB:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, func() { zr.Close() }, nil
+	case ELFCOMPRESS_ZSTD:
+		zr, err := zstd.NewReader(bytes.NewReader(data), zstd.WithDecoderConcurrency(1))
+		if err != nil {
+			return nil, nil, err
+		}
+		return zr, zr.Close, nil
+	}
+	return nil, nil, fmt.Errorf("compression type %s is not supported", CompressionTypeName(typ))
+}
+
+// ReadSectionContents reads the contents of a section, decompressing
+// SHF_COMPRESSED sections. The compression header is returned for those,
+// and nil for sections stored as is. ZLIB and ZSTD compression are
+// supported.
+func ReadSectionContents(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, shdrwn Elf64ShdrWithName) ([]byte, *Elf64Chdr, error) {
+	data, err := ReadSectionData(file, shdrwn)
+	if err != nil || shdrwn.Flags&SHF_COMPRESSED == 0 {
+		return data, nil, err
+	}
+
+	chdr, size, err := readCompressionHeader(data, ehdr, order)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading compression header of section '%s': %w", shdrwn.Name, err)
+	}
+	zr, release, err := decompressor(chdr.Type, data[size:])
+	if err != nil {
+		return nil, &chdr, fmt.Errorf("decompressing section '%s': %w", shdrwn.Name, err)
+	}
+	defer release()
+
+	// Read one byte past ch_size so that a section which decompresses to
+	// more than its header says is caught without inflating all of it
+	limit := int64(chdr.Size) + 1
+	if chdr.Size >= math.MaxInt64 {
+		limit = math.MaxInt64
+	}
+	contents, err := ioutil.ReadAll(io.LimitReader(zr, limit))
+	if err != nil {
+		return nil, &chdr, fmt.Errorf("decompressing section '%s': %w", shdrwn.Name, err)
+	}
+	if uint64(len(contents)) > chdr.Size {
+		return nil, &chdr, fmt.Errorf("section '%s' decompresses to more than the %d bytes its header says",
+			shdrwn.Name, chdr.Size)
+	}
+	if uint64(len(contents)) < chdr.Size {
+		return nil, &chdr, fmt.Errorf("section '%s' decompressed to %d bytes, but its header says %d",
+			shdrwn.Name, len(contents), chdr.Size)
+	}
+	return contents, &chdr, nil
+}
@@ -0,0 +1,89 @@
+package elfreader
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// compressedSection returns the data of an SHF_COMPRESSED section: a
+// compression header with the given ch_type and ch_size, then contents
+// compressed with that type
+func compressedSection(t *testing.T, order binary.ByteOrder, typ uint32, size uint64, contents []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := binary.Write(&buf, order, Elf64Chdr{Type: typ, Size: size, Addralign: 1}); err != nil {
+		t.Fatal(err)
+	}
+	switch typ {
+	case ELFCOMPRESS_ZLIB:
+		zw := zlib.NewWriter(&buf)
+		zw.Write(contents)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	case ELFCOMPRESS_ZSTD:
+		zw, err := zstd.NewWriter(&buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		zw.Write(contents)
+		if err := zw.Close(); err != nil {
+			t.Fatal(err)
+		}
+	default:
+		buf.Write(contents)
+	}
+	return buf.Bytes()
+}
+
+func TestReadSectionContents(t *testing.T) {
+	order := binary.LittleEndian
+	contents := []byte(strings.Repeat("GCC: (GNU) 13.2.0\x00", 64))
+	tests := []struct {
+		name string
+		typ  uint32
+		size uint64
+		err  string
+	}{
+		{"zlib", ELFCOMPRESS_ZLIB, uint64(len(contents)), ""},
+		{"zstd", ELFCOMPRESS_ZSTD, uint64(len(contents)), ""},
+		{"longer than ch_size", ELFCOMPRESS_ZLIB, 16, "section '.comment' decompresses to more than the 16 bytes its header says"},
+		{"shorter than ch_size", ELFCOMPRESS_ZSTD, 4096, "section '.comment' decompressed to 1152 bytes, but its header says 4096"},
+		{"unknown type", 7, uint64(len(contents)), "decompressing section '.comment': compression type <unknown: 7> is not supported"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := Parse(buildELF(order, nil, []testSection{
+				{name: ".comment", typ: SHT_PROGBITS, flags: SHF_COMPRESSED, data: compressedSection(t, order, tt.typ, tt.size, contents)},
+			}))
+			if err != nil {
+				t.Fatal(err)
+			}
+			shdrwns, err := f.Sections()
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, chdr, err := ReadSectionContents(f, f.Ehdr, f.Order, shdrwns[1])
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("error = %v, want %q", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if chdr == nil || chdr.Type != tt.typ {
+				t.Errorf("compression header = %+v, want type %d", chdr, tt.typ)
+			}
+			if !bytes.Equal(data, contents) {
+				t.Errorf("contents = %q, want %q", data, contents)
+			}
+		})
+	}
+}
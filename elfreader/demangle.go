@@ -0,0 +1,760 @@
+package elfreader
+
+import (
+	"strconv"
+	"strings"
+)
+
+// builtinTypes maps the one-letter codes of the Itanium C++ ABI to the
+// builtin types they stand for
+var builtinTypes = map[byte]string{
+	'v': "void",
+	'w': "wchar_t",
+	'b': "bool",
+	'c': "char",
+	'a': "signed char",
+	'h': "unsigned char",
+	's': "short",
+	't': "unsigned short",
+	'i': "int",
+	'j': "unsigned int",
+	'l': "long",
+	'm': "unsigned long",
+	'x': "long long",
+	'y': "unsigned long long",
+	'n': "__int128",
+	'o': "unsigned __int128",
+	'f': "float",
+	'd': "double",
+	'e': "long double",
+	'g': "__float128",
+	'z': "...",
+}
+
+// operatorNames maps the two-letter operator codes to their spelling
+var operatorNames = map[string]string{
+	"nw": "new", "na": "new[]", "dl": "delete", "da": "delete[]",
+	"ps": "+", "ng": "-", "ad": "&", "de": "*", "co": "~",
+	"pl": "+", "mi": "-", "ml": "*", "dv": "/", "rm": "%",
+	"an": "&", "or": "|", "eo": "^", "aS": "=",
+	"pL": "+=", "mI": "-=", "mL": "*=", "dV": "/=", "rM": "%=",
+	"aN": "&=", "oR": "|=", "eO": "^=",
+	"ls": "<<", "rs": ">>", "lS": "<<=", "rS": ">>=",
+	"eq": "==", "ne": "!=", "lt": "<", "gt": ">", "le": "<=", "ge": ">=", "ss": "<=>",
+	"nt": "!", "aa": "&&", "oo": "||", "pp": "++", "mm": "--",
+	"cm": ",", "pm": "->*", "pt": "->", "cl": "()", "ix": "[]", "qu": "?",
+}
+
+// standardSubstitutions are the predefined S<x> abbreviations
+var standardSubstitutions = map[byte]string{
+	'a': "std::allocator",
+	'b': "std::basic_string",
+	's': "std::basic_string<char, std::char_traits<char>, std::allocator<char> >",
+	'i': "std::basic_istream<char, std::char_traits<char> >",
+	'o': "std::basic_ostream<char, std::char_traits<char> >",
+	'd': "std::basic_iostream<char, std::char_traits<char> >",
+}
+
+// Demangle decodes a C++ symbol name mangled under the Itanium ABI, as GCC
+// and Clang do, so that _ZN3fooC1Ev reads foo::foo(). Only the common
+// cases are handled: names it does not understand are returned unchanged.
+func Demangle(name string) string {
+	// Versioned symbols carry their version after an '@'
+	base, version := name, ""
+	if i := strings.IndexByte(name, '@'); i > 0 {
+		base, version = name[:i], name[i:]
+	}
+	if !strings.HasPrefix(base, "_Z") {
+		return name
+	}
+
+	d := &demangler{s: base, pos: 2}
+	result, ok := d.run()
+	if !ok {
+		return name
+	}
+	return result + version
+}
+
+// demangler holds the state of one Demangle call: the input, the
+// components seen so far that later S_ references can name, and the
+// template arguments that T_ references name
+type demangler struct {
+	s        string
+	pos      int
+	subs     []string
+	tmplArgs []string
+}
+
+// demangleError aborts a demangle that meets something it can't decode
+type demangleError struct{}
+
+// demangledName is a decoded <name> along with what the rest of the
+// encoding needs to know about it
+type demangledName struct {
+	s string
+	// tmplArgs is set when the name ends with template arguments, in
+	// which case a function's return type is encoded
+	tmplArgs []string
+	// noReturn is set for constructors, destructors and conversions,
+	// which never have their return type encoded
+	noReturn bool
+	// qualifiers of a member function, such as " const"
+	qualifiers string
+}
+
+func (d *demangler) run() (result string, ok bool) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, isErr := r.(demangleError); !isErr {
+				panic(r)
+			}
+			ok = false
+		}
+	}()
+
+	result = d.encoding()
+	// GCC appends .constprop.0, .isra.0 and the like to cloned functions
+	for d.peek() == '.' && d.pos+1 < len(d.s) && isCloneChar(d.s[d.pos+1]) {
+		end := d.pos + 1
+		for end < len(d.s) && isCloneChar(d.s[end]) {
+			end++
+		}
+		for end+1 < len(d.s) && d.s[end] == '.' && d.s[end+1] >= '0' && d.s[end+1] <= '9' {
+			end += 2
+			for end < len(d.s) && d.s[end] >= '0' && d.s[end] <= '9' {
+				end++
+			}
+		}
+		result += " [clone " + d.s[d.pos:end] + "]"
+		d.pos = end
+	}
+	if d.pos != len(d.s) {
+		d.fail()
+	}
+	return result, true
+}
+
+// isCloneChar reports whether c can appear in the name of a clone suffix
+func isCloneChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c == '_'
+}
+
+func (d *demangler) fail() {
+	panic(demangleError{})
+}
+
+func (d *demangler) peek() byte {
+	if d.pos >= len(d.s) {
+		return 0
+	}
+	return d.s[d.pos]
+}
+
+func (d *demangler) next() byte {
+	if d.pos >= len(d.s) {
+		d.fail()
+	}
+	c := d.s[d.pos]
+	d.pos++
+	return c
+}
+
+func (d *demangler) expect(c byte) {
+	if d.next() != c {
+		d.fail()
+	}
+}
+
+func (d *demangler) hasPrefix(prefix string) bool {
+	return strings.HasPrefix(d.s[d.pos:], prefix)
+}
+
+func (d *demangler) addSub(s string) {
+	d.subs = append(d.subs, s)
+}
+
+// number reads a decimal number, negative when prefixed by 'n'
+func (d *demangler) number() string {
+	sign := ""
+	if d.peek() == 'n' {
+		d.pos++
+		sign = "-"
+	}
+	start := d.pos
+	for d.peek() >= '0' && d.peek() <= '9' {
+		d.pos++
+	}
+	if d.pos == start {
+		d.fail()
+	}
+	return sign + d.s[start:d.pos]
+}
+
+// encoding decodes a function or data name, or one of the special names
+// of vtables, typeinfo and thunks
+func (d *demangler) encoding() string {
+	if d.peek() == 'T' || d.hasPrefix("GV") {
+		return d.specialName()
+	}
+
+	n := d.name()
+	if c := d.peek(); c == 0 || c == 'E' || c == '.' {
+		return n.s
+	}
+
+	saved := d.tmplArgs
+	if n.tmplArgs != nil {
+		d.tmplArgs = n.tmplArgs
+	}
+	ret := ""
+	if n.tmplArgs != nil && !n.noReturn {
+		ret = d.typ() + " "
+	}
+	params := d.bareFunctionType()
+	d.tmplArgs = saved
+	return ret + n.s + params + n.qualifiers
+}
+
+func (d *demangler) specialName() string {
+	switch {
+	case d.hasPrefix("TV"):
+		d.pos += 2
+		return "vtable for " + d.typ()
+	case d.hasPrefix("TT"):
+		d.pos += 2
+		return "VTT for " + d.typ()
+	case d.hasPrefix("TI"):
+		d.pos += 2
+		return "typeinfo for " + d.typ()
+	case d.hasPrefix("TS"):
+		d.pos += 2
+		return "typeinfo name for " + d.typ()
+	case d.hasPrefix("GV"):
+		d.pos += 2
+		return "guard variable for " + d.name().s
+	case d.hasPrefix("Th"):
+		d.pos += 2
+		d.number()
+		d.expect('_')
+		return "non-virtual thunk to " + d.encoding()
+	case d.hasPrefix("Tv"):
+		d.pos += 2
+		d.number()
+		d.expect('_')
+		d.number()
+		d.expect('_')
+		return "virtual thunk to " + d.encoding()
+	}
+	d.fail()
+	return ""
+}
+
+func (d *demangler) name() demangledName {
+	switch {
+	case d.peek() == 'N':
+		return d.nestedName()
+	case d.peek() == 'Z':
+		return d.localName()
+	case d.hasPrefix("St"):
+		d.pos += 2
+		s, noReturn := d.unqualifiedName("")
+		return d.templateName("std::"+s, noReturn)
+	case d.peek() == 'S':
+		// A substitution can only name a template here
+		s := d.substitution()
+		if d.peek() != 'I' {
+			d.fail()
+		}
+		args := d.templateArgs()
+		return demangledName{s: withTemplate(s, args), tmplArgs: args}
+	}
+	s, noReturn := d.unqualifiedName("")
+	return d.templateName(s, noReturn)
+}
+
+// templateName completes an unscoped name with its template arguments, if
+// any follow it
+func (d *demangler) templateName(s string, noReturn bool) demangledName {
+	if d.peek() != 'I' {
+		return demangledName{s: s, noReturn: noReturn}
+	}
+	d.addSub(s)
+	args := d.templateArgs()
+	return demangledName{s: withTemplate(s, args), tmplArgs: args, noReturn: noReturn}
+}
+
+// nestedName decodes N [qualifiers] prefix... E, adding every prefix but
+// the complete name to the substitutions
+func (d *demangler) nestedName() demangledName {
+	d.expect('N')
+	var n demangledName
+	var restrict, volatile, constant bool
+	for {
+		switch d.peek() {
+		case 'r':
+			restrict = true
+		case 'V':
+			volatile = true
+		case 'K':
+			constant = true
+		default:
+			goto qualified
+		}
+		d.pos++
+	}
+qualified:
+	if constant {
+		n.qualifiers += " const"
+	}
+	if volatile {
+		n.qualifiers += " volatile"
+	}
+	if restrict {
+		n.qualifiers += " restrict"
+	}
+	switch d.peek() {
+	case 'R':
+		d.pos++
+		n.qualifiers += " &"
+	case 'O':
+		d.pos++
+		n.qualifiers += " &&"
+	}
+
+	prefix, last := "", ""
+	for d.peek() != 'E' {
+		substituted := false
+		switch {
+		case d.hasPrefix("St"):
+			d.pos += 2
+			prefix, last = "std", "std"
+			continue
+		case d.peek() == 'S':
+			prefix = d.substitution()
+			last = baseName(prefix)
+			n.tmplArgs = nil
+			substituted = true
+		case d.peek() == 'I':
+			if prefix == "" {
+				d.fail()
+			}
+			n.tmplArgs = d.templateArgs()
+			prefix = withTemplate(prefix, n.tmplArgs)
+		case d.peek() == 'T':
+			prefix = d.templateParam()
+			last = baseName(prefix)
+			n.tmplArgs = nil
+		default:
+			s, noReturn := d.unqualifiedName(last)
+			if prefix != "" {
+				prefix += "::"
+			}
+			prefix += s
+			if !noReturn {
+				last = baseName(s)
+			}
+			n.noReturn = noReturn
+			n.tmplArgs = nil
+		}
+		if !substituted && d.peek() != 'E' {
+			d.addSub(prefix)
+		}
+	}
+	d.pos++
+	if prefix == "" {
+		d.fail()
+	}
+	n.s = prefix
+	return n
+}
+
+// localName decodes Z encoding E name, an entity declared in a function
+func (d *demangler) localName() demangledName {
+	d.expect('Z')
+	function := d.encoding()
+	d.expect('E')
+
+	var n demangledName
+	if d.peek() == 's' {
+		d.pos++
+		n.s = function + "::string literal"
+	} else {
+		n = d.name()
+		n.s = function + "::" + n.s
+	}
+
+	// Discriminators tell apart entities of the same name
+	if d.peek() == '_' {
+		d.pos++
+		if d.peek() == '_' {
+			d.pos++
+			d.number()
+			d.expect('_')
+		} else {
+			d.number()
+		}
+	}
+	return n
+}
+
+// unqualifiedName decodes a source name, an operator, or a constructor or
+// destructor of the class named last. It reports whether the name is one
+// of those without an encoded return type.
+func (d *demangler) unqualifiedName(last string) (string, bool) {
+	c := d.peek()
+	switch {
+	case c >= '0' && c <= '9':
+		return d.abiTags(d.sourceName()), false
+	case c == 'L':
+		// Names with internal linkage
+		d.pos++
+		return d.abiTags(d.sourceName()), false
+	case c == 'C':
+		d.pos++
+		if c := d.next(); c < '1' || c > '5' || last == "" {
+			d.fail()
+		}
+		return d.abiTags(last), true
+	case c == 'D' && d.pos+1 < len(d.s) && d.s[d.pos+1] >= '0' && d.s[d.pos+1] <= '5':
+		d.pos += 2
+		if last == "" {
+			d.fail()
+		}
+		return d.abiTags("~" + last), true
+	case d.hasPrefix("cv"):
+		d.pos += 2
+		return "operator " + d.typ(), true
+	case c >= 'a' && c <= 'z' && d.pos+2 <= len(d.s):
+		op, ok := operatorNames[d.s[d.pos:d.pos+2]]
+		if !ok {
+			d.fail()
+		}
+		d.pos += 2
+		if op[0] >= 'a' && op[0] <= 'z' {
+			return d.abiTags("operator " + op), false
+		}
+		return d.abiTags("operator" + op), false
+	}
+	d.fail()
+	return "", false
+}
+
+// abiTags appends any B<source-name> ABI tags to name
+func (d *demangler) abiTags(name string) string {
+	for d.peek() == 'B' {
+		d.pos++
+		name += "[abi:" + d.sourceName() + "]"
+	}
+	return name
+}
+
+// sourceName decodes an identifier prefixed by its length
+func (d *demangler) sourceName() string {
+	n, err := strconv.Atoi(d.number())
+	if err != nil || n <= 0 || n > len(d.s)-d.pos {
+		d.fail()
+	}
+	id := d.s[d.pos : d.pos+n]
+	d.pos += n
+	if strings.HasPrefix(id, "_GLOBAL__N") {
+		return "(anonymous namespace)"
+	}
+	return id
+}
+
+// substitution decodes S_, S<seq-id>_ or one of the standard abbreviations
+func (d *demangler) substitution() string {
+	d.expect('S')
+	c := d.next()
+	if s, ok := standardSubstitutions[c]; ok {
+		return s
+	}
+
+	index := 0
+	if c != '_' {
+		n := 0
+		for ; c != '_'; c = d.next() {
+			// The index only grows with each digit, so one already past
+			// the list is rejected before it can overflow
+			if n >= len(d.subs) {
+				d.fail()
+			}
+			switch {
+			case c >= '0' && c <= '9':
+				n = n*36 + int(c-'0')
+			case c >= 'A' && c <= 'Z':
+				n = n*36 + int(c-'A') + 10
+			default:
+				d.fail()
+			}
+		}
+		index = n + 1
+	}
+	if index >= len(d.subs) {
+		d.fail()
+	}
+	return d.subs[index]
+}
+
+// templateParam decodes T_ or T<n>_, a reference to a template argument
+func (d *demangler) templateParam() string {
+	d.expect('T')
+	index := 0
+	if d.peek() != '_' {
+		n, err := strconv.Atoi(d.number())
+		if err != nil {
+			d.fail()
+		}
+		index = n + 1
+	}
+	d.expect('_')
+	if index < 0 || index >= len(d.tmplArgs) {
+		d.fail()
+	}
+	return d.tmplArgs[index]
+}
+
+func (d *demangler) templateArgs() []string {
+	d.expect('I')
+	args := []string{}
+	for d.peek() != 'E' {
+		args = append(args, d.templateArg())
+	}
+	d.pos++
+	return args
+}
+
+func (d *demangler) templateArg() string {
+	switch d.peek() {
+	case 'L':
+		return d.literal()
+	case 'J':
+		// An argument pack
+		d.pos++
+		var args []string
+		for d.peek() != 'E' {
+			args = append(args, d.templateArg())
+		}
+		d.pos++
+		return strings.Join(args, ", ")
+	}
+	return d.typ()
+}
+
+// literal decodes L<type><value>E, an integer, boolean or enumerator
+// template argument
+func (d *demangler) literal() string {
+	d.expect('L')
+	c := d.peek()
+	t, ok := builtinTypes[c]
+	if ok {
+		d.pos++
+	} else {
+		// An enumerator, shown as a cast of its value
+		t = d.typ()
+	}
+	value := d.number()
+	d.expect('E')
+
+	switch c {
+	case 'b':
+		if value == "0" {
+			return "false"
+		}
+		return "true"
+	case 'i':
+		return value
+	case 'j':
+		return value + "u"
+	case 'l':
+		return value + "l"
+	case 'm':
+		return value + "ul"
+	case 'x':
+		return value + "ll"
+	case 'y':
+		return value + "ull"
+	}
+	return "(" + t + ")" + value
+}
+
+// bareFunctionType decodes a parameter list, where a lone void means none
+func (d *demangler) bareFunctionType() string {
+	var params []string
+	for c := d.peek(); c != 0 && c != 'E' && c != '.'; c = d.peek() {
+		if c == 'v' && len(params) == 0 {
+			d.pos++
+			params = append(params, "")
+			continue
+		}
+		params = append(params, d.typ())
+	}
+	if len(params) == 0 {
+		d.fail()
+	}
+	if len(params) == 1 && params[0] == "" {
+		return "()"
+	}
+	return "(" + strings.Join(params, ", ") + ")"
+}
+
+func (d *demangler) typ() string {
+	c := d.peek()
+	if t, ok := builtinTypes[c]; ok {
+		d.pos++
+		return t
+	}
+
+	var t string
+	switch {
+	case c == 'P' || c == 'R' || c == 'O':
+		d.pos++
+		symbol := map[byte]string{'P': "*", 'R': "&", 'O': "&&"}[c]
+		if d.peek() == 'F' {
+			ret, params := d.functionType()
+			t = ret + " (" + symbol + ")" + params
+		} else {
+			t = d.typ() + symbol
+		}
+	case c == 'K' || c == 'V' || c == 'r':
+		var qualifiers string
+		for {
+			switch d.peek() {
+			case 'r':
+				qualifiers = " restrict" + qualifiers
+			case 'V':
+				qualifiers = " volatile" + qualifiers
+			case 'K':
+				qualifiers = " const" + qualifiers
+			default:
+				goto qualified
+			}
+			d.pos++
+		}
+	qualified:
+		t = d.typ() + qualifiers
+	case c == 'F':
+		ret, params := d.functionType()
+		t = ret + " " + params
+	case c == 'A':
+		d.pos++
+		size := d.number()
+		d.expect('_')
+		t = d.typ() + " [" + size + "]"
+	case c == 'D':
+		d.pos++
+		switch d.next() {
+		case 'n':
+			return "decltype(nullptr)"
+		case 'i':
+			return "char32_t"
+		case 's':
+			return "char16_t"
+		case 'u':
+			return "char8_t"
+		case 'f':
+			return "decimal32"
+		case 'd':
+			return "decimal64"
+		case 'e':
+			return "decimal128"
+		}
+		d.fail()
+	case c == 'u':
+		// A vendor extended type
+		d.pos++
+		t = d.sourceName()
+	case c == 'T':
+		t = d.templateParam()
+		if d.peek() == 'I' {
+			d.addSub(t)
+			t = withTemplate(t, d.templateArgs())
+		}
+	case d.hasPrefix("St"):
+		d.pos += 2
+		s, _ := d.unqualifiedName("")
+		t = "std::" + s
+		if d.peek() == 'I' {
+			d.addSub(t)
+			t = withTemplate(t, d.templateArgs())
+		}
+	case c == 'S':
+		t = d.substitution()
+		if d.peek() != 'I' {
+			return t
+		}
+		t = withTemplate(t, d.templateArgs())
+	case c == 'N' || c == 'Z' || c >= '0' && c <= '9':
+		t = d.name().s
+	default:
+		d.fail()
+	}
+	d.addSub(t)
+	return t
+}
+
+// functionType decodes F [Y] return-type parameters [ref-qualifier] E
+func (d *demangler) functionType() (string, string) {
+	d.expect('F')
+	if d.peek() == 'Y' {
+		d.pos++
+	}
+	ret := d.typ()
+	params := d.bareFunctionType()
+	switch d.peek() {
+	case 'R':
+		d.pos++
+		params += " &"
+	case 'O':
+		d.pos++
+		params += " &&"
+	}
+	d.expect('E')
+	return ret, params
+}
+
+// withTemplate appends template arguments to name, keeping a closing '>'
+// of the last one apart from the list's own, and the list apart from an
+// operator< or operator<<
+func withTemplate(name string, args []string) string {
+	if strings.HasSuffix(name, "<") {
+		name += " "
+	}
+	s := name + "<" + strings.Join(args, ", ")
+	if strings.HasSuffix(s, ">") {
+		s += " "
+	}
+	return s + ">"
+}
+
+// baseName returns the last component of a qualified name without its
+// template arguments or ABI tags, the name a constructor of that class goes by
+func baseName(s string) string {
+	for strings.HasSuffix(s, "]") {
+		i := strings.LastIndex(s, "[abi:")
+		if i < 0 {
+			break
+		}
+		s = s[:i]
+	}
+	if strings.HasSuffix(s, ">") {
+		depth := 0
+		for i := len(s) - 1; i >= 0; i-- {
+			switch s[i] {
+			case '>':
+				depth++
+			case '<':
+				depth--
+			}
+			if depth == 0 {
+				s = s[:i]
+				break
+			}
+		}
+	}
+	if i := strings.LastIndex(s, "::"); i >= 0 {
+		s = s[i+2:]
+	}
+	return s
+}
@@ -0,0 +1,30 @@
+package elfreader
+
+import "testing"
+
+func TestDemangle(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"_ZN3fooC1Ev", "foo::foo()"},
+		{"_Z3fooPKc", "foo(char const*)"},
+		{"_ZN3foo3barES_", "foo::bar(foo)"},
+		{"_ZSt4cout", "std::cout"},
+		{"_ZNSt6vectorIiSaIiEE9push_backERKi", "std::vector<int, std::allocator<int> >::push_back(int const&)"},
+		{"_Z3fooILi1EEvv@GLIBC_2.2.5", "void foo<1>()@GLIBC_2.2.5"},
+		{"main", "main"},
+
+		// Malformed names are left as they are
+		{"_Z1fS0_", "_Z1fS0_"},
+		{"_ZS2000000000000_", "_ZS2000000000000_"},
+		{"_ZSZZZZZZZZZZZZZZZZZZZZZZZZ_", "_ZSZZZZZZZZZZZZZZZZZZZZZZZZ_"},
+		{"_ZN3foo", "_ZN3foo"},
+		{"_Z99foo", "_Z99foo"},
+	}
+	for _, tt := range tests {
+		if got := Demangle(tt.name); got != tt.want {
+			t.Errorf("Demangle(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
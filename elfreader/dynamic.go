@@ -0,0 +1,227 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Dynamic array tags
+const (
+	DT_NULL     = 0
+	DT_NEEDED   = 1
+	DT_HASH     = 4
+	DT_STRTAB   = 5
+	DT_STRSZ    = 10
+	DT_SONAME   = 14
+	DT_RPATH    = 15
+	DT_RUNPATH  = 29
+	DT_GNU_HASH = 0x6ffffef5
+)
+
+type Elf64Dyn struct {
+	Tag int64  `json:"Tag" yaml:"Tag"`
+	Val uint64 `json:"Val" yaml:"Val"`
+}
+
+type Elf32Dyn struct {
+	Tag int32
+	Val uint32
+}
+
+// dynamicTagNames maps DT_* tags to the names used by readelf
+var dynamicTagNames = map[int64]string{
+	0:          "NULL",
+	1:          "NEEDED",
+	2:          "PLTRELSZ",
+	3:          "PLTGOT",
+	4:          "HASH",
+	5:          "STRTAB",
+	6:          "SYMTAB",
+	7:          "RELA",
+	8:          "RELASZ",
+	9:          "RELAENT",
+	10:         "STRSZ",
+	11:         "SYMENT",
+	12:         "INIT",
+	13:         "FINI",
+	14:         "SONAME",
+	15:         "RPATH",
+	16:         "SYMBOLIC",
+	17:         "REL",
+	18:         "RELSZ",
+	19:         "RELENT",
+	20:         "PLTREL",
+	21:         "DEBUG",
+	22:         "TEXTREL",
+	23:         "JMPREL",
+	24:         "BIND_NOW",
+	25:         "INIT_ARRAY",
+	26:         "FINI_ARRAY",
+	27:         "INIT_ARRAYSZ",
+	28:         "FINI_ARRAYSZ",
+	29:         "RUNPATH",
+	30:         "FLAGS",
+	32:         "PREINIT_ARRAY",
+	33:         "PREINIT_ARRAYSZ",
+	0x6ffffef5: "GNU_HASH",
+	0x6ffffff0: "VERSYM",
+	0x6ffffff9: "RELACOUNT",
+	0x6ffffffa: "RELCOUNT",
+	0x6ffffffb: "FLAGS_1",
+	0x6ffffffc: "VERDEF",
+	0x6ffffffd: "VERDEFNUM",
+	0x6ffffffe: "VERNEED",
+	0x6fffffff: "VERNEEDNUM",
+}
+
+// DynamicTagName returns the readelf-style name of a DT_* tag
+func DynamicTagName(tag int64) string {
+	if name, ok := dynamicTagNames[tag]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", tag)
+}
+
+// DynamicSection is the decoded dynamic array along with the string table
+// its string-valued tags refer to
+type DynamicSection struct {
+	Offset  uint64     `json:"Offset" yaml:"Offset"`
+	Entries []Elf64Dyn `json:"Entries" yaml:"Entries"`
+	Strtab  []byte     `json:"-" yaml:"-" xml:"-"`
+}
+
+// readDynamic reads one dynamic array entry from rd,
+// widening 32-bit entries to the 64-bit layout
+func readDynamic(rd io.Reader, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Dyn, error) {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		var dyn32 Elf32Dyn
+		if err := readStruct(rd, order, &dyn32); err != nil {
+			return Elf64Dyn{}, err
+		}
+		return Elf64Dyn{Tag: int64(dyn32.Tag), Val: uint64(dyn32.Val)}, nil
+	}
+
+	var dyn Elf64Dyn
+	err := readStruct(rd, order, &dyn)
+	return dyn, err
+}
+
+// ReadDynamic locates the dynamic array through the .dynamic section, or the
+// PT_DYNAMIC segment when section headers are missing, and reads its entries
+// up to and including the DT_NULL terminator. It returns nil for files
+// without a dynamic array.
+func ReadDynamic(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*DynamicSection, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var dynamic *DynamicSection
+	var size uint64
+	strtabIndex := -1
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Type == SHT_DYNAMIC {
+			dynamic = &DynamicSection{Offset: shdrwn.Offset}
+			size = shdrwn.Size
+			strtabIndex = int(shdrwn.Link)
+			break
+		}
+	}
+	if dynamic == nil {
+		for _, phdr := range phdrs {
+			if phdr.Type == PT_DYNAMIC {
+				dynamic = &DynamicSection{Offset: phdr.Offset}
+				size = phdr.Filesz
+				break
+			}
+		}
+	}
+	if dynamic == nil {
+		return nil, nil
+	}
+
+	entsize := uint64(binary.Size(Elf64Dyn{}))
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		entsize = uint64(binary.Size(Elf32Dyn{}))
+	}
+	rd := readerAt(file, dynamic.Offset)
+	for i := uint64(0); i < size/entsize; i++ {
+		dyn, err := readDynamic(rd, ehdr, order)
+		if err != nil {
+			return nil, fmt.Errorf("reading dynamic entry %d: %w", i, err)
+		}
+		dynamic.Entries = append(dynamic.Entries, dyn)
+		if dyn.Tag == DT_NULL {
+			break
+		}
+	}
+
+	// Prefer the string table linked from the section header, falling back
+	// to DT_STRTAB/DT_STRSZ mapped through the loadable segments
+	if strtabIndex > 0 && strtabIndex < len(shdrwns) {
+		dynamic.Strtab, err = ReadStringTable(file, shdrwns[strtabIndex].Offset, shdrwns[strtabIndex].Size)
+	} else {
+		var strAddr, strSize uint64
+		for _, dyn := range dynamic.Entries {
+			switch dyn.Tag {
+			case DT_STRTAB:
+				strAddr = dyn.Val
+			case DT_STRSZ:
+				strSize = dyn.Val
+			}
+		}
+		if strOffset, found := VaddrToOffset(phdrs, strAddr); found {
+			dynamic.Strtab, err = ReadStringTable(file, strOffset, strSize)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return dynamic, nil
+}
+
+// Dependencies lists the shared libraries a file links against and the
+// search paths it asks the dynamic linker to use
+type Dependencies struct {
+	Needed  []string `json:"Needed" yaml:"Needed"`
+	Rpath   string   `json:"Rpath" yaml:"Rpath"`
+	Runpath string   `json:"Runpath" yaml:"Runpath"`
+}
+
+// ReadDependencies resolves the DT_NEEDED, DT_RPATH and DT_RUNPATH entries
+// of the dynamic array. It returns nil for statically linked files.
+func ReadDependencies(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*Dependencies, error) {
+	dynamic, err := ReadDynamic(file, ehdr, order)
+	if err != nil || dynamic == nil {
+		return nil, err
+	}
+
+	deps := &Dependencies{}
+	for _, dyn := range dynamic.Entries {
+		switch dyn.Tag {
+		case DT_NEEDED:
+			deps.Needed = append(deps.Needed, GetString(dynamic.Strtab, uint32(dyn.Val)))
+		case DT_RPATH:
+			deps.Rpath = GetString(dynamic.Strtab, uint32(dyn.Val))
+		case DT_RUNPATH:
+			deps.Runpath = GetString(dynamic.Strtab, uint32(dyn.Val))
+		}
+	}
+	return deps, nil
+}
+
+// ReadInterpreter reads the program interpreter path held by a PT_INTERP
+// segment, up to its NUL terminator
+func ReadInterpreter(file io.ReaderAt, phdr Elf64Phdr) (string, error) {
+	data, err := ReadBytes(file, phdr.Offset, phdr.Filesz)
+	if err != nil {
+		return "", fmt.Errorf("reading program interpreter at offset 0x%x: %w", phdr.Offset, err)
+	}
+	return GetString(data, 0), nil
+}
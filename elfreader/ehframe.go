@@ -0,0 +1,286 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// DWARF exception header pointer encodings. The low nibble selects the
+// value format and the high nibble what the value is relative to.
+const (
+	DW_EH_PE_absptr   = 0x00
+	DW_EH_PE_uleb128  = 0x01
+	DW_EH_PE_udata2   = 0x02
+	DW_EH_PE_udata4   = 0x03
+	DW_EH_PE_udata8   = 0x04
+	DW_EH_PE_sleb128  = 0x09
+	DW_EH_PE_sdata2   = 0x0a
+	DW_EH_PE_sdata4   = 0x0b
+	DW_EH_PE_sdata8   = 0x0c
+	DW_EH_PE_pcrel    = 0x10
+	DW_EH_PE_textrel  = 0x20
+	DW_EH_PE_datarel  = 0x30
+	DW_EH_PE_funcrel  = 0x40
+	DW_EH_PE_aligned  = 0x50
+	DW_EH_PE_indirect = 0x80
+	DW_EH_PE_omit     = 0xff
+)
+
+var ehPointerFormatNames = map[uint8]string{
+	DW_EH_PE_absptr:  "absptr",
+	DW_EH_PE_uleb128: "uleb128",
+	DW_EH_PE_udata2:  "udata2",
+	DW_EH_PE_udata4:  "udata4",
+	DW_EH_PE_udata8:  "udata8",
+	DW_EH_PE_sleb128: "sleb128",
+	DW_EH_PE_sdata2:  "sdata2",
+	DW_EH_PE_sdata4:  "sdata4",
+	DW_EH_PE_sdata8:  "sdata8",
+}
+
+var ehPointerBaseNames = map[uint8]string{
+	DW_EH_PE_pcrel:   "pcrel",
+	DW_EH_PE_textrel: "textrel",
+	DW_EH_PE_datarel: "datarel",
+	DW_EH_PE_funcrel: "funcrel",
+	DW_EH_PE_aligned: "aligned",
+}
+
+// EHPointerEncodingName describes a DW_EH_PE_* encoding byte, such as
+// "datarel sdata4"
+func EHPointerEncodingName(enc uint8) string {
+	if enc == DW_EH_PE_omit {
+		return "omit"
+	}
+
+	var parts []string
+	if enc&DW_EH_PE_indirect != 0 {
+		parts = append(parts, "indirect")
+	}
+	if base, ok := ehPointerBaseNames[enc&0x70]; ok {
+		parts = append(parts, base)
+	} else if enc&0x70 != 0 {
+		parts = append(parts, fmt.Sprintf("<unknown base: 0x%x>", enc&0x70))
+	}
+	if format, ok := ehPointerFormatNames[enc&0x0f]; ok {
+		parts = append(parts, format)
+	} else {
+		parts = append(parts, fmt.Sprintf("<unknown format: 0x%x>", enc&0x0f))
+	}
+	return strings.Join(parts, " ")
+}
+
+// EHFrameHdrEntry is one row of the .eh_frame_hdr binary search table
+type EHFrameHdrEntry struct {
+	InitialLocation uint64 `json:"InitialLocation" yaml:"InitialLocation"`
+	Address         uint64 `json:"Address" yaml:"Address"`
+}
+
+// EHFrameHdr is the decoded .eh_frame_hdr that the unwinder uses to find the
+// FDE covering a program counter. Pointers are resolved to virtual
+// addresses.
+type EHFrameHdr struct {
+	Offset        uint64            `json:"Offset" yaml:"Offset"`
+	Vaddr         uint64            `json:"Vaddr" yaml:"Vaddr"`
+	Version       uint8             `json:"Version" yaml:"Version"`
+	EHFramePtrEnc uint8             `json:"EHFramePtrEnc" yaml:"EHFramePtrEnc"`
+	FDECountEnc   uint8             `json:"FDECountEnc" yaml:"FDECountEnc"`
+	TableEnc      uint8             `json:"TableEnc" yaml:"TableEnc"`
+	EHFramePtr    uint64            `json:"EHFramePtr" yaml:"EHFramePtr"`
+	FDECount      uint64            `json:"FDECount" yaml:"FDECount"`
+	Table         []EHFrameHdrEntry `json:"Table" yaml:"Table"`
+}
+
+var errEHFrameHdrTruncated = errors.New("eh_frame_hdr is truncated")
+
+// ehPointerDecoder reads DW_EH_PE_* encoded values from the data of an
+// .eh_frame_hdr mapped at vaddr
+type ehPointerDecoder struct {
+	data    []byte
+	order   binary.ByteOrder
+	vaddr   uint64
+	ptrSize int
+	pos     int
+}
+
+// fixed reads an n-byte value, sign-extending it when signed is set
+func (d *ehPointerDecoder) fixed(n int, signed bool) (uint64, error) {
+	if d.pos+n > len(d.data) {
+		return 0, errEHFrameHdrTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	switch n {
+	case 2:
+		v := d.order.Uint16(b)
+		if signed {
+			return uint64(int64(int16(v))), nil
+		}
+		return uint64(v), nil
+	case 4:
+		v := d.order.Uint32(b)
+		if signed {
+			return uint64(int64(int32(v))), nil
+		}
+		return uint64(v), nil
+	}
+	return d.order.Uint64(b), nil
+}
+
+// leb128 reads an unsigned or signed LEB128 value
+func (d *ehPointerDecoder) leb128(signed bool) (uint64, error) {
+	var v uint64
+	var shift uint
+	for {
+		if d.pos >= len(d.data) {
+			return 0, errEHFrameHdrTruncated
+		}
+		b := d.data[d.pos]
+		d.pos++
+		if shift < 64 {
+			v |= uint64(b&0x7f) << shift
+		}
+		shift += 7
+		if b&0x80 == 0 {
+			if signed && shift < 64 && b&0x40 != 0 {
+				v |= ^uint64(0) << shift
+			}
+			return v, nil
+		}
+	}
+}
+
+// pointer reads one value in the given encoding and applies its base.
+// Only pcrel and datarel can be resolved from the header alone; both are
+// relative to addresses within .eh_frame_hdr.
+func (d *ehPointerDecoder) pointer(enc uint8) (uint64, error) {
+	if enc == DW_EH_PE_omit {
+		return 0, nil
+	}
+	fieldAddr := d.vaddr + uint64(d.pos)
+
+	var v uint64
+	var err error
+	switch enc & 0x0f {
+	case DW_EH_PE_absptr:
+		v, err = d.fixed(d.ptrSize, false)
+	case DW_EH_PE_uleb128:
+		v, err = d.leb128(false)
+	case DW_EH_PE_sleb128:
+		v, err = d.leb128(true)
+	case DW_EH_PE_udata2, DW_EH_PE_sdata2:
+		v, err = d.fixed(2, enc&0x0f == DW_EH_PE_sdata2)
+	case DW_EH_PE_udata4, DW_EH_PE_sdata4:
+		v, err = d.fixed(4, enc&0x0f == DW_EH_PE_sdata4)
+	case DW_EH_PE_udata8, DW_EH_PE_sdata8:
+		v, err = d.fixed(8, false)
+	default:
+		return 0, fmt.Errorf("unsupported pointer encoding 0x%02x", enc)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	switch enc & 0x70 {
+	case DW_EH_PE_pcrel:
+		v += fieldAddr
+	case DW_EH_PE_datarel:
+		v += d.vaddr
+	}
+	return v, nil
+}
+
+// parseEHFrameHdr decodes the header fields and the binary search table
+func parseEHFrameHdr(data []byte, order binary.ByteOrder, vaddr uint64, ptrSize int) (*EHFrameHdr, error) {
+	if len(data) < 4 {
+		return nil, errEHFrameHdrTruncated
+	}
+	hdr := &EHFrameHdr{
+		Vaddr:         vaddr,
+		Version:       data[0],
+		EHFramePtrEnc: data[1],
+		FDECountEnc:   data[2],
+		TableEnc:      data[3],
+	}
+	if hdr.Version != 1 {
+		return hdr, fmt.Errorf("unsupported eh_frame_hdr version %d", hdr.Version)
+	}
+
+	d := &ehPointerDecoder{data: data, order: order, vaddr: vaddr, ptrSize: ptrSize, pos: 4}
+	var err error
+	if hdr.EHFramePtr, err = d.pointer(hdr.EHFramePtrEnc); err != nil {
+		return hdr, fmt.Errorf("reading eh_frame_ptr: %w", err)
+	}
+	if hdr.FDECountEnc == DW_EH_PE_omit || hdr.TableEnc == DW_EH_PE_omit {
+		return hdr, nil
+	}
+	if hdr.FDECount, err = d.pointer(hdr.FDECountEnc); err != nil {
+		return hdr, fmt.Errorf("reading fde_count: %w", err)
+	}
+
+	for i := uint64(0); i < hdr.FDECount; i++ {
+		var entry EHFrameHdrEntry
+		if entry.InitialLocation, err = d.pointer(hdr.TableEnc); err == nil {
+			entry.Address, err = d.pointer(hdr.TableEnc)
+		}
+		if err != nil {
+			return hdr, fmt.Errorf("reading table entry %d: %w", i, err)
+		}
+		hdr.Table = append(hdr.Table, entry)
+	}
+	return hdr, nil
+}
+
+// ReadEHFrameHdr decodes the PT_GNU_EH_FRAME segment, or the .eh_frame_hdr
+// section when there is no such segment. It returns nil when the file has
+// neither.
+func ReadEHFrameHdr(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*EHFrameHdr, error) {
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var offset, vaddr, size uint64
+	found := false
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_GNU_EH_FRAME {
+			offset, vaddr, size = phdr.Offset, phdr.Vaddr, phdr.Filesz
+			found = true
+			break
+		}
+	}
+	if !found {
+		shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+		if err != nil {
+			return nil, err
+		}
+		for _, shdrwn := range shdrwns {
+			if shdrwn.Name == ".eh_frame_hdr" && shdrwn.Type != SHT_NOBITS {
+				offset, vaddr, size = shdrwn.Offset, shdrwn.Addr, shdrwn.Size
+				found = true
+				break
+			}
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+
+	data, err := ReadBytes(file, offset, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading eh_frame_hdr at offset 0x%x: %w", offset, err)
+	}
+	ptrSize := 8
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		ptrSize = 4
+	}
+	hdr, err := parseEHFrameHdr(data, order, vaddr, ptrSize)
+	if err != nil {
+		return nil, err
+	}
+	hdr.Offset = offset
+	return hdr, nil
+}
@@ -0,0 +1,730 @@
+// Package elfreader decodes the headers and tables of 32 and 64-bit ELF files
+// of either byte order. Every reader returns data structures and errors and
+// leaves presentation to the caller.
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ELF identification indexes and values
+const (
+	EI_CLASS      = 4
+	EI_DATA       = 5
+	EI_VERSION    = 6
+	EI_OSABI      = 7
+	EI_ABIVERSION = 8
+	EI_PAD        = 9
+	ELFCLASS32    = 1
+	ELFCLASS64    = 2
+	ELFDATA2LSB   = 1
+	ELFDATA2MSB   = 2
+	EV_CURRENT    = 1
+)
+
+// Section header types
+const (
+	SHT_NULL     = 0
+	SHT_PROGBITS = 1
+	SHT_SYMTAB   = 2
+	SHT_STRTAB   = 3
+	SHT_RELA     = 4
+	SHT_HASH     = 5
+	SHT_DYNAMIC  = 6
+	SHT_NOTE     = 7
+	SHT_NOBITS   = 8
+	SHT_REL      = 9
+	SHT_DYNSYM   = 11
+	SHT_GNU_HASH = 0x6ffffff6
+)
+
+// Section header flags
+const (
+	SHF_WRITE            = 0x1
+	SHF_ALLOC            = 0x2
+	SHF_EXECINSTR        = 0x4
+	SHF_MERGE            = 0x10
+	SHF_STRINGS          = 0x20
+	SHF_INFO_LINK        = 0x40
+	SHF_LINK_ORDER       = 0x80
+	SHF_OS_NONCONFORMING = 0x100
+	SHF_GROUP            = 0x200
+	SHF_TLS              = 0x400
+	SHF_COMPRESSED       = 0x800
+	SHF_EXCLUDE          = 0x80000000
+)
+
+// Escape values for extended section and program header numbering
+const (
+	SHN_XINDEX = 0xffff
+	PN_XNUM    = 0xffff
+)
+
+// Special section indexes of symbols
+const (
+	SHN_UNDEF     = 0
+	SHN_LORESERVE = 0xff00
+	SHN_LOPROC    = 0xff00
+	SHN_HIPROC    = 0xff1f
+	SHN_LOOS      = 0xff20
+	SHN_HIOS      = 0xff3f
+	SHN_ABS       = 0xfff1
+	SHN_COMMON    = 0xfff2
+)
+
+// Program header types
+const (
+	PT_LOAD         = 1
+	PT_DYNAMIC      = 2
+	PT_INTERP       = 3
+	PT_NOTE         = 4
+	PT_PHDR         = 6
+	PT_TLS          = 7
+	PT_GNU_EH_FRAME = 0x6474e550
+)
+
+// Program header flags
+const (
+	PF_X = 1
+	PF_W = 2
+	PF_R = 4
+)
+
+// Object file types
+const (
+	ET_REL  = 1
+	ET_EXEC = 2
+	ET_DYN  = 3
+	ET_CORE = 4
+)
+
+// Machine types
+const (
+	EM_386     = 3
+	EM_ARM     = 40
+	EM_X86_64  = 62
+	EM_AARCH64 = 183
+)
+
+type Elf64Ehdr struct {
+	Ident     [16]byte `json:"Ident" yaml:"Ident" xml:"Ident"`
+	Type      uint16   `json:"Type" yaml:"Type" xml:"Type"`
+	Machine   uint16   `json:"Machine" yaml:"Machine" xml:"Machine"`
+	Version   uint32   `json:"Version" yaml:"Version" xml:"Version"`
+	Entry     uint64   `json:"Entry" yaml:"Entry" xml:"Entry"`
+	Phoff     uint64   `json:"Phoff" yaml:"Phoff" xml:"Phoff"`
+	Shoff     uint64   `json:"Shoff" yaml:"Shoff" xml:"Shoff"`
+	Flags     uint32   `json:"Flags" yaml:"Flags" xml:"Flags"`
+	Ehsize    uint16   `json:"Ehsize" yaml:"Ehsize" xml:"Ehsize"`
+	Phentsize uint16   `json:"Phentsize" yaml:"Phentsize" xml:"Phentsize"`
+	Phnum     uint16   `json:"Phnum" yaml:"Phnum" xml:"Phnum"`
+	Shentsize uint16   `json:"Shentsize" yaml:"Shentsize" xml:"Shentsize"`
+	Shnum     uint16   `json:"Shnum" yaml:"Shnum" xml:"Shnum"`
+	Shstrndx  uint16   `json:"Shstrndx" yaml:"Shstrndx" xml:"Shstrndx"`
+}
+
+// MarshalJSON encodes the header with Ident as a hex string rather than an
+// array of 16 numbers, so that headers are easy to read and compare. The
+// type, machine and OS/ABI are followed by their names.
+func (h Elf64Ehdr) MarshalJSON() ([]byte, error) {
+	type ehdr Elf64Ehdr
+	return json.Marshal(struct {
+		Ident string `json:"Ident"`
+		ehdr
+		TypeName    string `json:"TypeName"`
+		MachineName string `json:"MachineName"`
+		OSABIName   string `json:"OSABIName"`
+	}{hex.EncodeToString(h.Ident[:]), ehdr(h), TypeName(h.Type), MachineName(h.Machine), OSABIName(h.Ident[EI_OSABI])})
+}
+
+// MarshalXML encodes the header with Ident as a hex string, which
+// encoding/xml would otherwise write as one element per byte
+func (h Elf64Ehdr) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	type ehdr Elf64Ehdr
+	return e.EncodeElement(struct {
+		Ident string `xml:"Ident"`
+		ehdr
+	}{hex.EncodeToString(h.Ident[:]), ehdr(h)}, start)
+}
+
+type Elf32Ehdr struct {
+	Ident     [16]byte
+	Type      uint16
+	Machine   uint16
+	Version   uint32
+	Entry     uint32
+	Phoff     uint32
+	Shoff     uint32
+	Flags     uint32
+	Ehsize    uint16
+	Phentsize uint16
+	Phnum     uint16
+	Shentsize uint16
+	Shnum     uint16
+	Shstrndx  uint16
+}
+
+type Elf64Phdr struct {
+	Type   uint32 `json:"Type" yaml:"Type" xml:"Type"`
+	Flags  uint32 `json:"Flags" yaml:"Flags" xml:"Flags"`
+	Offset uint64 `json:"Offset" yaml:"Offset" xml:"Offset"`
+	Vaddr  uint64 `json:"Vaddr" yaml:"Vaddr" xml:"Vaddr"`
+	Paddr  uint64 `json:"Paddr" yaml:"Paddr" xml:"Paddr"`
+	Filesz uint64 `json:"Filesz" yaml:"Filesz" xml:"Filesz"`
+	Memsz  uint64 `json:"Memsz" yaml:"Memsz" xml:"Memsz"`
+	Align  uint64 `json:"Align" yaml:"Align" xml:"Align"`
+}
+
+// MarshalJSON adds the name of the segment type to the JSON encoding
+func (h Elf64Phdr) MarshalJSON() ([]byte, error) {
+	type phdr Elf64Phdr
+	return json.Marshal(struct {
+		phdr
+		TypeName string `json:"TypeName"`
+	}{phdr(h), PhdrTypeName(h.Type)})
+}
+
+type Elf32Phdr struct {
+	Type   uint32
+	Offset uint32
+	Vaddr  uint32
+	Paddr  uint32
+	Filesz uint32
+	Memsz  uint32
+	Flags  uint32
+	Align  uint32
+}
+
+type Elf64Shdr struct {
+	Name      uint32
+	Type      uint32
+	Flags     uint64
+	Addr      uint64
+	Offset    uint64
+	Size      uint64
+	Link      uint32
+	Info      uint32
+	Addralign uint64
+	Entsize   uint64
+}
+
+type Elf32Shdr struct {
+	Name      uint32
+	Type      uint32
+	Flags     uint32
+	Addr      uint32
+	Offset    uint32
+	Size      uint32
+	Link      uint32
+	Info      uint32
+	Addralign uint32
+	Entsize   uint32
+}
+
+// Elf64ShdrWithName is a section header with its name resolved. Index is
+// its position in the section header table, kept so that the section can
+// be identified once headers are filtered or reordered.
+type Elf64ShdrWithName struct {
+	Index     int    `json:"Index" yaml:"Index" xml:"Index"`
+	Name      string `json:"Name" yaml:"Name" xml:"Name"`
+	Type      uint32 `json:"Type" yaml:"Type" xml:"Type"`
+	Flags     uint64 `json:"Flags" yaml:"Flags" xml:"Flags"`
+	Addr      uint64 `json:"Addr" yaml:"Addr" xml:"Addr"`
+	Offset    uint64 `json:"Offset" yaml:"Offset" xml:"Offset"`
+	Size      uint64 `json:"Size" yaml:"Size" xml:"Size"`
+	Link      uint32 `json:"Link" yaml:"Link" xml:"Link"`
+	Info      uint32 `json:"Info" yaml:"Info" xml:"Info"`
+	Addralign uint64 `json:"Addralign" yaml:"Addralign" xml:"Addralign"`
+	Entsize   uint64 `json:"Entsize" yaml:"Entsize" xml:"Entsize"`
+}
+
+// MarshalJSON adds the name of the section type to the JSON encoding
+func (h Elf64ShdrWithName) MarshalJSON() ([]byte, error) {
+	type shdr Elf64ShdrWithName
+	return json.Marshal(struct {
+		shdr
+		TypeName string `json:"TypeName"`
+	}{shdr(h), SectionTypeName(h.Type)})
+}
+
+// ByteOrder returns the byte order selected by the EI_DATA identification byte
+func ByteOrder(ident [16]byte) binary.ByteOrder {
+	if ident[EI_DATA] == ELFDATA2MSB {
+		return binary.BigEndian
+	}
+	return binary.LittleEndian
+}
+
+// ReadELFHeader reads the ELF header, branching on EI_CLASS so that 32-bit
+// headers are widened into an Elf64Ehdr. The byte order detected from
+// EI_DATA is returned for decoding the rest of the file.
+func ReadELFHeader(file io.ReaderAt) (*Elf64Ehdr, binary.ByteOrder, error) {
+	return ReadELFHeaderForced(file, 0, 0)
+}
+
+// ReadELFHeaderForced reads the ELF header as ReadELFHeader does, but uses
+// class and encoding in place of the EI_CLASS and EI_DATA bytes, so that a
+// file whose identification bytes are damaged can still be read. A zero
+// class or encoding is taken from the file. The values used are stored in
+// the Ident of the returned header, so every reader given it follows them.
+func ReadELFHeaderForced(file io.ReaderAt, class, encoding byte) (*Elf64Ehdr, binary.ByteOrder, error) {
+	ehdr, order, err := readELFHeader(file, class, encoding)
+	if err != nil {
+		return nil, nil, err
+	}
+	if class != 0 {
+		ehdr.Ident[EI_CLASS] = class
+	}
+	if encoding != 0 {
+		ehdr.Ident[EI_DATA] = encoding
+	}
+	return ehdr, order, nil
+}
+
+func readELFHeader(file io.ReaderAt, class, encoding byte) (*Elf64Ehdr, binary.ByteOrder, error) {
+	var ident [16]byte
+	data, err := readHeader(file, len(ident))
+	if err != nil {
+		return nil, nil, err
+	}
+	copy(ident[:], data)
+	if ident[0] != 0x7f || ident[1] != 'E' || ident[2] != 'L' || ident[3] != 'F' {
+		if format := otherFormat(ident[:]); format != "" {
+			return nil, nil, fmt.Errorf("this looks like %s, not ELF", format)
+		}
+		return nil, nil, errors.New("not an ELF file: bad magic")
+	}
+	if class != 0 {
+		ident[EI_CLASS] = class
+	}
+	if encoding != 0 {
+		ident[EI_DATA] = encoding
+	}
+	order := ByteOrder(ident)
+
+	if ident[EI_CLASS] == ELFCLASS32 {
+		var ehdr32 Elf32Ehdr
+		data, err := readHeader(file, binary.Size(ehdr32))
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := readStruct(bytes.NewReader(data), order, &ehdr32); err != nil {
+			return nil, nil, err
+		}
+		return &Elf64Ehdr{
+			Ident:     ehdr32.Ident,
+			Type:      ehdr32.Type,
+			Machine:   ehdr32.Machine,
+			Version:   ehdr32.Version,
+			Entry:     uint64(ehdr32.Entry),
+			Phoff:     uint64(ehdr32.Phoff),
+			Shoff:     uint64(ehdr32.Shoff),
+			Flags:     ehdr32.Flags,
+			Ehsize:    ehdr32.Ehsize,
+			Phentsize: ehdr32.Phentsize,
+			Phnum:     ehdr32.Phnum,
+			Shentsize: ehdr32.Shentsize,
+			Shnum:     ehdr32.Shnum,
+			Shstrndx:  ehdr32.Shstrndx,
+		}, order, nil
+	}
+
+	ehdr := new(Elf64Ehdr)
+	data, err = readHeader(file, binary.Size(ehdr))
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := readStruct(bytes.NewReader(data), order, ehdr); err != nil {
+		return nil, nil, err
+	}
+	return ehdr, order, nil
+}
+
+// ELF_MAGIC starts every ELF file
+const ELF_MAGIC = "\x7fELF"
+
+// IsELF reports whether the file starts with the ELF magic number
+func IsELF(file io.ReaderAt) bool {
+	magic := make([]byte, len(ELF_MAGIC))
+	n, _ := file.ReadAt(magic, 0)
+	return n == len(magic) && string(magic) == ELF_MAGIC
+}
+
+// otherFormat names the binary format other than ELF that the magic at
+// the start of a file belongs to, or returns "" for one it does not know.
+// Files are only recognized, not read.
+func otherFormat(magic []byte) string {
+	switch {
+	case bytes.HasPrefix(magic, []byte(ARCHIVE_MAGIC)):
+		return "an ar archive"
+	case bytes.HasPrefix(magic, []byte("MZ")):
+		return "a PE (Windows) binary"
+	}
+	switch binary.BigEndian.Uint32(magic) {
+	case 0xfeedface, 0xfeedfacf, 0xcefaedfe, 0xcffaedfe:
+		return "a Mach-O binary"
+	case 0xcafebabe:
+		// Java class files share the magic of universal binaries
+		return "a Mach-O universal binary"
+	}
+	return ""
+}
+
+// readHeader reads the first size bytes of the file. A file too short to
+// hold them is reported along with its length, which tells an empty or
+// truncated file apart from a damaged header.
+func readHeader(file io.ReaderAt, size int) ([]byte, error) {
+	data := make([]byte, size)
+	n, err := file.ReadAt(data, 0)
+	if n == size {
+		return data, nil
+	}
+	if err == io.EOF {
+		return nil, fmt.Errorf("file too small to be an ELF (%d bytes)", n)
+	}
+	return nil, err
+}
+
+// HeaderSizes holds the sizes of the ELF header and of the program and
+// section header table entries
+type HeaderSizes struct {
+	Ehsize    uint16
+	Phentsize uint16
+	Shentsize uint16
+}
+
+// ExpectedHeaderSizes returns the sizes e_ehsize, e_phentsize and
+// e_shentsize should hold for the class of the file
+func ExpectedHeaderSizes(ehdr *Elf64Ehdr) HeaderSizes {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		return HeaderSizes{
+			Ehsize:    uint16(binary.Size(Elf32Ehdr{})),
+			Phentsize: uint16(binary.Size(Elf32Phdr{})),
+			Shentsize: uint16(binary.Size(Elf32Shdr{})),
+		}
+	}
+	return HeaderSizes{
+		Ehsize:    uint16(binary.Size(Elf64Ehdr{})),
+		Phentsize: uint16(binary.Size(Elf64Phdr{})),
+		Shentsize: uint16(binary.Size(Elf64Shdr{})),
+	}
+}
+
+// checkEntrySize rejects a table whose declared entry size is too small to
+// hold an entry. Larger entries are allowed, as readers step over the
+// declared size and ignore the extra bytes.
+func checkEntrySize(name string, declared, expected uint16) error {
+	if declared < expected {
+		return fmt.Errorf("%s entry size %d is smaller than the %d bytes of an entry", name, declared, expected)
+	}
+	return nil
+}
+
+// ReadProgramHeaders loads the whole program header table into a slice.
+// When e_phnum is PN_XNUM the real count is taken from the sh_info field of
+// section header 0. A *File reads the table only once.
+func ReadProgramHeaders(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]Elf64Phdr, error) {
+	if f, ok := file.(*File); ok && f.Ehdr == ehdr {
+		return f.ProgramHeaders()
+	}
+	return readProgramHeaders(file, ehdr, order)
+}
+
+// WalkProgramHeaders reads the program header table and calls fn with each
+// entry in turn, stopping at the first error fn returns
+func WalkProgramHeaders(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, fn func(index int, phdr Elf64Phdr) error) error {
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	for i, phdr := range phdrs {
+		if err := fn(i, phdr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func readProgramHeaders(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]Elf64Phdr, error) {
+	phnum := uint64(ehdr.Phnum)
+	if ehdr.Phnum == PN_XNUM && ehdr.Shoff != 0 {
+		shdr0, err := readFirstSectionHeader(file, ehdr, order)
+		if err != nil {
+			return nil, err
+		}
+		phnum = uint64(shdr0.Info)
+	}
+
+	if phnum == 0 {
+		return nil, nil
+	}
+	err := checkEntrySize("program header table", ehdr.Phentsize, ExpectedHeaderSizes(ehdr).Phentsize)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTableBounds(file, "program header table", ehdr.Phoff, phnum, uint64(ehdr.Phentsize))
+	if err != nil {
+		return nil, err
+	}
+	var phdrs []Elf64Phdr
+
+	for i := uint64(0); i < phnum; i++ {
+		phdr, err := readProgramHeader(readerAt(file, ehdr.Phoff+i*uint64(ehdr.Phentsize)), ehdr, order)
+		if err != nil {
+			return nil, fmt.Errorf("reading program header %d: %w", i, err)
+		}
+		phdrs = append(phdrs, phdr)
+	}
+	return phdrs, nil
+}
+
+// readProgramHeader reads one program header from rd,
+// widening 32-bit entries to the 64-bit layout
+func readProgramHeader(rd io.Reader, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Phdr, error) {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		var phdr32 Elf32Phdr
+		if err := readStruct(rd, order, &phdr32); err != nil {
+			return Elf64Phdr{}, err
+		}
+		return Elf64Phdr{
+			Type:   phdr32.Type,
+			Flags:  phdr32.Flags,
+			Offset: uint64(phdr32.Offset),
+			Vaddr:  uint64(phdr32.Vaddr),
+			Paddr:  uint64(phdr32.Paddr),
+			Filesz: uint64(phdr32.Filesz),
+			Memsz:  uint64(phdr32.Memsz),
+			Align:  uint64(phdr32.Align),
+		}, nil
+	}
+
+	var phdr Elf64Phdr
+	err := readStruct(rd, order, &phdr)
+	return phdr, err
+}
+
+// readFirstSectionHeader reads section header 0, which carries the real
+// values of e_phnum, e_shnum and e_shstrndx when they overflow
+func readFirstSectionHeader(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Shdr, error) {
+	err := checkEntrySize("section header table", ehdr.Shentsize, ExpectedHeaderSizes(ehdr).Shentsize)
+	if err != nil {
+		return Elf64Shdr{}, err
+	}
+	err = checkTableBounds(file, "section header table", ehdr.Shoff, 1, uint64(ehdr.Shentsize))
+	if err != nil {
+		return Elf64Shdr{}, err
+	}
+	shdr0, err := readSectionHeader(readerAt(file, ehdr.Shoff), ehdr, order)
+	if err != nil {
+		return Elf64Shdr{}, fmt.Errorf("reading section header 0: %w", err)
+	}
+	return shdr0, nil
+}
+
+// sectionCount returns the number of section headers and the index of the
+// section header string table. Files with more than 0xff00 sections set
+// e_shnum to 0 and e_shstrndx to SHN_XINDEX and keep the real values in the
+// sh_size and sh_link fields of section header 0.
+func sectionCount(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (uint64, uint32, error) {
+	count := uint64(ehdr.Shnum)
+	strndx := uint32(ehdr.Shstrndx)
+	if ehdr.Shoff == 0 || (ehdr.Shnum != 0 && ehdr.Shstrndx != SHN_XINDEX) {
+		return count, strndx, nil
+	}
+
+	shdr0, err := readFirstSectionHeader(file, ehdr, order)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	if ehdr.Shnum == 0 {
+		count = shdr0.Size
+	}
+	if ehdr.Shstrndx == SHN_XINDEX {
+		strndx = shdr0.Link
+	}
+	return count, strndx, nil
+}
+
+// MakeSectionHeaderWithName reads every section header and resolves its name
+// through the section header string table. A *File reads them only once.
+func MakeSectionHeaderWithName(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]Elf64ShdrWithName, error) {
+	if f, ok := file.(*File); ok && f.Ehdr == ehdr {
+		return f.Sections()
+	}
+	return readSectionHeadersWithName(file, ehdr, order)
+}
+
+// WalkSections reads the section header table, resolving the section names,
+// and calls fn with each section in turn, stopping at the first error fn
+// returns
+func WalkSections(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, fn func(index int, shdrwn Elf64ShdrWithName) error) error {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	for i, shdrwn := range shdrwns {
+		if err := fn(i, shdrwn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// FindSection returns the first section named name
+func FindSection(shdrwns []Elf64ShdrWithName, name string) (Elf64ShdrWithName, bool) {
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Name == name {
+			return shdrwn, true
+		}
+	}
+	return Elf64ShdrWithName{}, false
+}
+
+// FindSectionWithPrefix returns the first section whose name starts with
+// prefix, such as ".debug_" or ".rela"
+func FindSectionWithPrefix(shdrwns []Elf64ShdrWithName, prefix string) (Elf64ShdrWithName, bool) {
+	for _, shdrwn := range shdrwns {
+		if strings.HasPrefix(shdrwn.Name, prefix) {
+			return shdrwn, true
+		}
+	}
+	return Elf64ShdrWithName{}, false
+}
+
+func readSectionHeadersWithName(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]Elf64ShdrWithName, error) {
+	shnum, shstrndx, err := sectionCount(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	if shnum == 0 {
+		return nil, nil
+	}
+
+	err = checkEntrySize("section header table", ehdr.Shentsize, ExpectedHeaderSizes(ehdr).Shentsize)
+	if err != nil {
+		return nil, err
+	}
+	err = checkTableBounds(file, "section header table", ehdr.Shoff, shnum, uint64(ehdr.Shentsize))
+	if err != nil {
+		return nil, err
+	}
+
+	// Load section headers into a slice
+	shdrs := make([]Elf64Shdr, shnum)
+	shdrwns := make([]Elf64ShdrWithName, shnum)
+	for i := range shdrs {
+		shdr, err := readSectionHeader(readerAt(file, ehdr.Shoff+uint64(i)*uint64(ehdr.Shentsize)), ehdr, order)
+		if err != nil {
+			return nil, fmt.Errorf("reading section header %d: %w", i, err)
+		}
+		shdrs[i] = shdr
+	}
+
+	// Load the section header string table
+	if uint64(shstrndx) >= shnum {
+		return nil, fmt.Errorf("invalid section header string table index %d (only %d sections)", shstrndx, shnum)
+	}
+	stringTable, err := ReadStringTable(file, shdrs[shstrndx].Offset, shdrs[shstrndx].Size)
+	if err != nil {
+		return nil, err
+	}
+
+	// Files without section names have no string table to check
+	if name := GetString(stringTable, shdrs[shstrndx].Name); shstrndx != SHN_UNDEF && name != ".shstrtab" {
+		warnf(file, "section header string table [%d] is named %q rather than \".shstrtab\"; the string table index may be wrong", shstrndx, name)
+	}
+
+	for i := range shdrs {
+		sectionName := GetString(stringTable, shdrs[i].Name)
+		shdrwns[i].Index = i
+		shdrwns[i].Name = sectionName
+		shdrwns[i].Type = shdrs[i].Type
+		shdrwns[i].Flags = shdrs[i].Flags
+		shdrwns[i].Addr = shdrs[i].Addr
+		shdrwns[i].Offset = shdrs[i].Offset
+		shdrwns[i].Size = shdrs[i].Size
+		shdrwns[i].Link = shdrs[i].Link
+		shdrwns[i].Info = shdrs[i].Info
+		shdrwns[i].Addralign = shdrs[i].Addralign
+		shdrwns[i].Entsize = shdrs[i].Entsize
+	}
+
+	return shdrwns, nil
+}
+
+// readSectionHeader reads one section header from rd,
+// widening 32-bit entries to the 64-bit layout
+func readSectionHeader(rd io.Reader, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Shdr, error) {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		var shdr32 Elf32Shdr
+		if err := readStruct(rd, order, &shdr32); err != nil {
+			return Elf64Shdr{}, err
+		}
+		return Elf64Shdr{
+			Name:      shdr32.Name,
+			Type:      shdr32.Type,
+			Flags:     uint64(shdr32.Flags),
+			Addr:      uint64(shdr32.Addr),
+			Offset:    uint64(shdr32.Offset),
+			Size:      uint64(shdr32.Size),
+			Link:      shdr32.Link,
+			Info:      shdr32.Info,
+			Addralign: uint64(shdr32.Addralign),
+			Entsize:   uint64(shdr32.Entsize),
+		}, nil
+	}
+
+	var shdr Elf64Shdr
+	err := readStruct(rd, order, &shdr)
+	return shdr, err
+}
+
+// SectionInSegment reports whether an allocated section lies within a
+// segment. Sections occupying file space are matched on their file range;
+// SHT_NOBITS sections such as .bss are matched on their memory range.
+func SectionInSegment(shdrwn Elf64ShdrWithName, phdr Elf64Phdr) bool {
+	if shdrwn.Flags&SHF_ALLOC == 0 {
+		return false
+	}
+	// Thread-local .tbss only occupies memory in the PT_TLS template
+	if shdrwn.Type == SHT_NOBITS && shdrwn.Flags&SHF_TLS != 0 && phdr.Type != PT_TLS {
+		return false
+	}
+
+	start, size := phdr.Offset, phdr.Filesz
+	pos := shdrwn.Offset
+	if shdrwn.Type == SHT_NOBITS {
+		start, size = phdr.Vaddr, phdr.Memsz
+		pos = shdrwn.Addr
+	}
+
+	// The ranges are compared by their sizes, as start+size can wrap
+	// around in a crafted file
+	if pos < start {
+		return false
+	}
+	if shdrwn.Size == 0 {
+		return pos-start < size
+	}
+	return shdrwn.Size <= size && pos-start <= size-shdrwn.Size
+}
+
+// VaddrToOffset translates a virtual address into a file offset using the
+// PT_LOAD segment that maps it
+func VaddrToOffset(phdrs []Elf64Phdr, vaddr uint64) (uint64, bool) {
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_LOAD && vaddr >= phdr.Vaddr && vaddr-phdr.Vaddr < phdr.Filesz {
+			return vaddr - phdr.Vaddr + phdr.Offset, true
+		}
+	}
+	return 0, false
+}
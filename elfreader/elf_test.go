@@ -0,0 +1,341 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// testEntry is the entry point of the files made by buildELF
+const testEntry = 0x401000
+
+// testSection is a section of a file made by buildELF
+type testSection struct {
+	name    string
+	typ     uint32
+	flags   uint64
+	addr    uint64
+	link    uint32
+	info    uint32
+	entsize uint64
+	data    []byte
+}
+
+// buildELF lays out a 64-bit x86-64 executable in the given byte order: the
+// ELF header, the program headers, the contents of the sections and last
+// the section header table. Section 0 is the null section, and .shstrtab is
+// added after the given sections.
+func buildELF(order binary.ByteOrder, phdrs []Elf64Phdr, sections []testSection) []byte {
+	ehdrSize := binary.Size(Elf64Ehdr{})
+	phdrSize := binary.Size(Elf64Phdr{})
+
+	var names bytes.Buffer
+	names.WriteByte(0)
+	sections = append(sections, testSection{name: ".shstrtab", typ: SHT_STRTAB})
+	shdrs := make([]Elf64Shdr, len(sections)+1)
+	for i, s := range sections {
+		shdrs[i+1] = Elf64Shdr{
+			Name:      uint32(names.Len()),
+			Type:      s.typ,
+			Flags:     s.flags,
+			Addr:      s.addr,
+			Link:      s.link,
+			Info:      s.info,
+			Addralign: 1,
+			Entsize:   s.entsize,
+		}
+		names.WriteString(s.name)
+		names.WriteByte(0)
+	}
+	sections[len(sections)-1].data = names.Bytes()
+
+	var contents bytes.Buffer
+	dataStart := ehdrSize + len(phdrs)*phdrSize
+	for i, s := range sections {
+		for contents.Len()%8 != 0 {
+			contents.WriteByte(0)
+		}
+		shdrs[i+1].Offset = uint64(dataStart + contents.Len())
+		shdrs[i+1].Size = uint64(len(s.data))
+		contents.Write(s.data)
+	}
+	for contents.Len()%8 != 0 {
+		contents.WriteByte(0)
+	}
+
+	ehdr := Elf64Ehdr{
+		Type:      ET_EXEC,
+		Machine:   EM_X86_64,
+		Version:   EV_CURRENT,
+		Entry:     testEntry,
+		Shoff:     uint64(dataStart + contents.Len()),
+		Ehsize:    uint16(ehdrSize),
+		Phentsize: uint16(phdrSize),
+		Phnum:     uint16(len(phdrs)),
+		Shentsize: uint16(binary.Size(Elf64Shdr{})),
+		Shnum:     uint16(len(shdrs)),
+		Shstrndx:  uint16(len(shdrs) - 1),
+	}
+	if len(phdrs) > 0 {
+		ehdr.Phoff = uint64(ehdrSize)
+	}
+	copy(ehdr.Ident[:], "\x7fELF")
+	ehdr.Ident[EI_CLASS] = ELFCLASS64
+	ehdr.Ident[EI_DATA] = ELFDATA2LSB
+	if order == binary.BigEndian {
+		ehdr.Ident[EI_DATA] = ELFDATA2MSB
+	}
+	ehdr.Ident[EI_VERSION] = EV_CURRENT
+
+	var buf bytes.Buffer
+	binary.Write(&buf, order, ehdr)
+	binary.Write(&buf, order, phdrs)
+	buf.Write(contents.Bytes())
+	binary.Write(&buf, order, shdrs)
+	return buf.Bytes()
+}
+
+// symbolData encodes symbol table entries
+func symbolData(order binary.ByteOrder, syms []Elf64Sym) []byte {
+	var buf bytes.Buffer
+	binary.Write(&buf, order, syms)
+	return buf.Bytes()
+}
+
+func TestReadELFHeaderBigEndian(t *testing.T) {
+	data := buildELF(binary.BigEndian, []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R, Vaddr: 0x400000, Filesz: 0x40, Memsz: 0x40},
+	}, []testSection{
+		{name: ".data", typ: 1, flags: SHF_WRITE | SHF_ALLOC, data: []byte("data")},
+	})
+
+	ehdr, order, err := ReadELFHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order != binary.BigEndian {
+		t.Errorf("byte order = %v, want big endian", order)
+	}
+	if ehdr.Type != ET_EXEC {
+		t.Errorf("Type = %d, want %d", ehdr.Type, ET_EXEC)
+	}
+	if ehdr.Machine != EM_X86_64 {
+		t.Errorf("Machine = %d, want %d", ehdr.Machine, EM_X86_64)
+	}
+	if ehdr.Entry != testEntry {
+		t.Errorf("Entry = 0x%x, want 0x%x", ehdr.Entry, testEntry)
+	}
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	phdrs, _ := f.ProgramHeaders()
+	if len(phdrs) != 1 || phdrs[0].Vaddr != 0x400000 || phdrs[0].Flags != PF_R {
+		t.Errorf("program headers = %+v", phdrs)
+	}
+	sections, _ := f.Sections()
+	if len(sections) != 3 || sections[1].Name != ".data" || sections[2].Name != ".shstrtab" {
+		t.Errorf("sections = %+v", sections)
+	}
+}
+
+func TestReadELFHeaderBadMagic(t *testing.T) {
+	data := []byte("#!/bin/sh\necho hi\n")
+	_, _, err := ReadELFHeader(bytes.NewReader(data))
+	if err == nil || err.Error() != "not an ELF file: bad magic" {
+		t.Errorf("error = %v, want bad magic", err)
+	}
+}
+
+func TestExtendedNumbering(t *testing.T) {
+	phdrs := []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R, Vaddr: 0x400000},
+		{Type: PT_LOAD, Flags: PF_R | PF_W, Vaddr: 0x600000},
+		{Type: PT_TLS, Flags: PF_R, Vaddr: 0x600000},
+	}
+	data := buildELF(binary.LittleEndian, phdrs, []testSection{
+		{name: ".data", typ: 1, flags: SHF_WRITE | SHF_ALLOC, data: []byte("data")},
+	})
+	ehdr, _, err := ReadELFHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shnum, shstrndx := ehdr.Shnum, ehdr.Shstrndx
+
+	// Move the counts into section header 0, as files with more than
+	// 0xffff segments or 0xff00 sections do
+	order := binary.LittleEndian
+	order.PutUint16(data[56:], PN_XNUM)
+	order.PutUint16(data[60:], 0)
+	order.PutUint16(data[62:], SHN_XINDEX)
+	shdr0 := data[ehdr.Shoff:]
+	order.PutUint64(shdr0[32:], uint64(shnum))
+	order.PutUint32(shdr0[40:], uint32(shstrndx))
+	order.PutUint32(shdr0[44:], uint32(len(phdrs)))
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := f.ProgramHeaders()
+	if len(got) != len(phdrs) || got[2].Type != PT_TLS {
+		t.Errorf("program headers = %+v, want %d segments", got, len(phdrs))
+	}
+	sections, _ := f.Sections()
+	if len(sections) != int(shnum) || sections[1].Name != ".data" || sections[shstrndx].Name != ".shstrtab" {
+		t.Errorf("sections = %+v, want %d sections", sections, shnum)
+	}
+}
+
+func TestReadELFHeaderTooSmall(t *testing.T) {
+	whole := buildELF(binary.LittleEndian, nil, nil)
+	tests := []struct {
+		size int
+		want string
+	}{
+		{0, "file too small to be an ELF (0 bytes)"},
+		{10, "file too small to be an ELF (10 bytes)"},
+		{40, "file too small to be an ELF (40 bytes)"},
+	}
+	for _, tt := range tests {
+		_, _, err := ReadELFHeader(bytes.NewReader(whole[:tt.size]))
+		if err == nil || err.Error() != tt.want {
+			t.Errorf("%d bytes: error = %v, want %q", tt.size, err, tt.want)
+		}
+	}
+}
+
+func TestShstrndxOutOfRange(t *testing.T) {
+	data := buildELF(binary.LittleEndian, nil, []testSection{
+		{name: ".data", typ: 1, flags: SHF_WRITE | SHF_ALLOC, data: []byte("data")},
+	})
+	binary.LittleEndian.PutUint16(data[62:], 7)
+
+	_, err := Parse(data)
+	want := "invalid section header string table index 7 (only 3 sections)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+}
+
+func TestSectionInSegment(t *testing.T) {
+	const top = 1<<64 - 1
+	segment := Elf64Phdr{Type: PT_LOAD, Offset: 0x1000, Vaddr: 0x401000, Filesz: 0x1000, Memsz: 0x2000}
+	high := Elf64Phdr{Type: PT_LOAD, Offset: top - 0xfff, Vaddr: top - 0xfff, Filesz: 0x1000, Memsz: 0x1000}
+	tests := []struct {
+		name    string
+		section Elf64ShdrWithName
+		phdr    Elf64Phdr
+		want    bool
+	}{
+		{"inside", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x1800, Size: 0x100}, segment, true},
+		{"at the end", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x1f00, Size: 0x100}, segment, true},
+		{"past the end", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x1f00, Size: 0x101}, segment, false},
+		{"before", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x800, Size: 0x100}, segment, false},
+		{"empty at the start", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x1000}, segment, true},
+		{"empty at the end", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: 0x2000}, segment, false},
+		{"not allocated", Elf64ShdrWithName{Type: 1, Offset: 0x1800, Size: 0x100}, segment, false},
+		{"bss in memory", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: SHT_NOBITS, Addr: 0x402800, Offset: 0x9000, Size: 0x100}, segment, true},
+		{"size wraps", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: top - 0xff, Size: 0x200}, segment, false},
+		{"segment at the top", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: 1, Offset: top - 0xff, Size: 0x100}, high, true},
+		{"bss at the top", Elf64ShdrWithName{Flags: SHF_ALLOC, Type: SHT_NOBITS, Addr: top - 0x7ff, Size: 0x800}, high, true},
+	}
+	for _, tt := range tests {
+		if got := SectionInSegment(tt.section, tt.phdr); got != tt.want {
+			t.Errorf("%s: SectionInSegment = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestVaddrToOffset(t *testing.T) {
+	const top = 1<<64 - 1
+	phdrs := []Elf64Phdr{
+		{Type: PT_LOAD, Offset: 0x1000, Vaddr: 0x401000, Filesz: 0x100, Memsz: 0x200},
+		{Type: PT_LOAD, Offset: 0x2000, Vaddr: top - 0xfff, Filesz: 0x1000, Memsz: 0x1000},
+	}
+	tests := []struct {
+		vaddr  uint64
+		offset uint64
+		found  bool
+	}{
+		{0x401000, 0x1000, true},
+		{0x4010ff, 0x10ff, true},
+		{0x401100, 0, false},
+		{top - 0xfff, 0x2000, true},
+		{top, 0x2fff, true},
+		{0x10, 0, false},
+	}
+	for _, tt := range tests {
+		offset, found := VaddrToOffset(phdrs, tt.vaddr)
+		if offset != tt.offset || found != tt.found {
+			t.Errorf("VaddrToOffset(0x%x) = 0x%x, %v, want 0x%x, %v", tt.vaddr, offset, found, tt.offset, tt.found)
+		}
+	}
+}
+
+func TestSectionNameTableWarning(t *testing.T) {
+	tests := []struct {
+		name     string
+		shstrndx uint16
+		want     []string
+	}{
+		{"right table", 3, nil},
+		{"wrong table", 1, []string{`section header string table [1] is named "GCC" rather than ".shstrtab"; the string table index may be wrong`}},
+		{"no section names", SHN_UNDEF, nil},
+	}
+	for _, tt := range tests {
+		data := buildELF(binary.LittleEndian, nil, []testSection{
+			{name: ".comment", typ: 1, data: []byte("\x00GCC\x00")},
+			{name: ".data", typ: 1, flags: SHF_WRITE | SHF_ALLOC, data: []byte("data")},
+		})
+		binary.LittleEndian.PutUint16(data[62:], tt.shstrndx)
+
+		f, err := NewFile(bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var warnings []string
+		f.Warn = func(msg string) { warnings = append(warnings, msg) }
+		if _, err := f.Sections(); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if strings.Join(warnings, "\n") != strings.Join(tt.want, "\n") {
+			t.Errorf("%s: warnings = %q, want %q", tt.name, warnings, tt.want)
+		}
+	}
+}
+
+func TestFindSection(t *testing.T) {
+	shdrwns := []Elf64ShdrWithName{
+		{Index: 0},
+		{Index: 1, Name: ".text"},
+		{Index: 2, Name: ".debug_info"},
+		{Index: 3, Name: ".debug_line"},
+		{Index: 4, Name: ".text.startup"},
+	}
+	tests := []struct {
+		name  string
+		find  func([]Elf64ShdrWithName, string) (Elf64ShdrWithName, bool)
+		arg   string
+		index int
+		found bool
+	}{
+		{"exact", FindSection, ".text", 1, true},
+		{"exact ignores longer names", FindSection, ".debug", 0, false},
+		{"absent", FindSection, ".data", 0, false},
+		{"prefix matching several", FindSectionWithPrefix, ".debug_", 2, true},
+		{"prefix matching the whole name", FindSectionWithPrefix, ".text", 1, true},
+		{"prefix absent", FindSectionWithPrefix, ".rela", 0, false},
+	}
+	for _, tt := range tests {
+		shdrwn, found := tt.find(shdrwns, tt.arg)
+		if found != tt.found || shdrwn.Index != tt.index {
+			t.Errorf("%s: found [%d] %q, %v for %q, want [%d], %v", tt.name, shdrwn.Index, shdrwn.Name, found, tt.arg, tt.index, tt.found)
+		}
+	}
+	if _, found := FindSection(nil, ".text"); found {
+		t.Error("found a section in an empty list")
+	}
+}
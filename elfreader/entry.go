@@ -0,0 +1,42 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+)
+
+// EntryBytes holds the first bytes of code at the entry point and where
+// they lie in the file. The structured outputs carry the bytes as Hex.
+type EntryBytes struct {
+	Entry  uint64 `json:"Entry" yaml:"Entry"`
+	Offset uint64 `json:"Offset" yaml:"Offset"`
+	Bytes  []byte `json:"-" yaml:"-" xml:"-"`
+	Hex    string `json:"Bytes" yaml:"Bytes" xml:"Bytes"`
+}
+
+// ReadEntryBytes reads up to n bytes at e_entry, found through the PT_LOAD
+// segment that maps it. Fewer bytes are returned when the segment ends
+// first. It returns nil for files without an entry point.
+func ReadEntryBytes(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, n uint64) (*EntryBytes, error) {
+	if ehdr.Entry == 0 {
+		return nil, nil
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	offset, size, found := segmentRemainder(phdrs, ehdr.Entry)
+	if !found {
+		return nil, fmt.Errorf("entry point 0x%x is not mapped by any loadable segment", ehdr.Entry)
+	}
+	if size > n {
+		size = n
+	}
+	data, err := ReadBytes(file, offset, size)
+	if err != nil {
+		return nil, fmt.Errorf("reading entry point code at offset 0x%x: %w", offset, err)
+	}
+	return &EntryBytes{Entry: ehdr.Entry, Offset: offset, Bytes: data, Hex: hex.EncodeToString(data)}, nil
+}
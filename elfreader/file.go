@@ -0,0 +1,103 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// File is an ELF file along with its header. Its program and section
+// headers are read the first time they are asked for and then kept, so that
+// every dump of a run shares one read of the header tables and of the
+// section name string table, and sees the same error if that read fails.
+// A *File can be passed as the io.ReaderAt of every reader in this package.
+//
+// Warn, when set, is called with a description of each problem that does
+// not stop the file from being read but suggests part of it is misread,
+// such as a section header string table that is not named .shstrtab.
+// Readers given a plain io.ReaderAt rather than a *File warn of nothing.
+type File struct {
+	io.ReaderAt
+	Ehdr  *Elf64Ehdr
+	Order binary.ByteOrder
+	Warn  func(msg string)
+
+	phdrsRead    bool
+	phdrs        []Elf64Phdr
+	phdrsErr     error
+	sectionsRead bool
+	sections     []Elf64ShdrWithName
+	sectionsErr  error
+}
+
+// NewFile reads the ELF header of r
+func NewFile(r io.ReaderAt) (*File, error) {
+	return NewFileForced(r, 0, 0)
+}
+
+// NewFileForced reads the ELF header of r with ReadELFHeaderForced, taking
+// the class and data encoding from the arguments unless they are zero
+func NewFileForced(r io.ReaderAt, class, encoding byte) (*File, error) {
+	ehdr, order, err := ReadELFHeaderForced(r, class, encoding)
+	if err != nil {
+		return nil, err
+	}
+	return &File{ReaderAt: r, Ehdr: ehdr, Order: order}, nil
+}
+
+// Size returns the size of the underlying file, as FileSize does
+func (f *File) Size() int64 {
+	return FileSize(f.ReaderAt)
+}
+
+// ProgramHeaders returns the program headers, reading them on the first
+// call. Callers get their own copy to modify.
+func (f *File) ProgramHeaders() ([]Elf64Phdr, error) {
+	if !f.phdrsRead {
+		f.phdrs, f.phdrsErr = readProgramHeaders(f.ReaderAt, f.Ehdr, f.Order)
+		f.phdrsRead = true
+	}
+	if f.phdrsErr != nil || f.phdrs == nil {
+		return nil, f.phdrsErr
+	}
+	return append([]Elf64Phdr{}, f.phdrs...), nil
+}
+
+// Sections returns the section headers with their names resolved, reading
+// them on the first call. Callers get their own copy to modify.
+func (f *File) Sections() ([]Elf64ShdrWithName, error) {
+	if !f.sectionsRead {
+		f.sections, f.sectionsErr = readSectionHeadersWithName(f, f.Ehdr, f.Order)
+		f.sectionsRead = true
+	}
+	if f.sectionsErr != nil || f.sections == nil {
+		return nil, f.sectionsErr
+	}
+	return append([]Elf64ShdrWithName{}, f.sections...), nil
+}
+
+// warnf passes a warning to the Warn function of file when it is a *File
+func warnf(file io.ReaderAt, format string, args ...interface{}) {
+	if f, ok := file.(*File); ok && f.Warn != nil {
+		f.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// Parse reads the ELF header, program headers and section headers of an
+// ELF image held in memory, returning the first error met. Malformed input
+// gives an error rather than a panic, which makes Parse the entry point for
+// fuzzing the header parsing.
+func Parse(data []byte) (*File, error) {
+	f, err := NewFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := f.ProgramHeaders(); err != nil {
+		return nil, err
+	}
+	if _, err := f.Sections(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
@@ -0,0 +1,109 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+func TestParseTruncated(t *testing.T) {
+	data := buildELF(binary.LittleEndian, []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R | PF_X, Vaddr: 0x400000, Filesz: 0x40, Memsz: 0x40},
+		{Type: PT_NOTE, Flags: PF_R, Vaddr: 0x400040},
+	}, []testSection{
+		{name: ".text", typ: 1, flags: SHF_ALLOC | SHF_EXECINSTR, data: []byte{0xc3}},
+	})
+	if _, err := Parse(data); err != nil {
+		t.Fatalf("whole file: %v", err)
+	}
+
+	ehdr, _, _ := ReadELFHeader(bytes.NewReader(data))
+	phdrSize := uint64(ehdr.Phentsize)
+	tests := []struct {
+		name string
+		size uint64
+	}{
+		{"identification", 10},
+		{"header", 40},
+		{"program headers", ehdr.Phoff + phdrSize + phdrSize/2},
+		{"section headers", ehdr.Shoff + uint64(ehdr.Shentsize)*2 + 8},
+		{"last byte", uint64(len(data)) - 1},
+	}
+	for _, tt := range tests {
+		if _, err := Parse(data[:tt.size]); err == nil {
+			t.Errorf("cut in the %s at %d bytes: no error", tt.name, tt.size)
+		}
+	}
+}
+
+func TestParseReorderedTables(t *testing.T) {
+	phdrs := []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R | PF_X, Vaddr: 0x400000, Filesz: 0x40, Memsz: 0x40},
+		{Type: PT_LOAD, Flags: PF_R | PF_W, Offset: 0x40, Vaddr: 0x401040, Filesz: 0x8, Memsz: 0x10},
+	}
+	data := buildELF(binary.LittleEndian, phdrs, []testSection{
+		{name: ".text", typ: 1, flags: SHF_ALLOC | SHF_EXECINSTR, data: []byte{0xc3}},
+	})
+	order := binary.LittleEndian
+
+	// Move the program headers to the end of the file, after the section
+	// headers, and clear where they were
+	tableSize := len(phdrs) * binary.Size(Elf64Phdr{})
+	phoff := len(data)
+	data = append(data, data[64:64+tableSize]...)
+	for i := 64; i < 64+tableSize; i++ {
+		data[i] = 0
+	}
+	order.PutUint64(data[32:], uint64(phoff))
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if f.Ehdr.Phoff <= f.Ehdr.Shoff {
+		t.Fatalf("program headers at 0x%x are not after the section headers at 0x%x", f.Ehdr.Phoff, f.Ehdr.Shoff)
+	}
+	got, _ := f.ProgramHeaders()
+	if len(got) != len(phdrs) || got[0] != phdrs[0] || got[1] != phdrs[1] {
+		t.Errorf("program headers = %+v, want %+v", got, phdrs)
+	}
+	sections, _ := f.Sections()
+	if len(sections) != 3 || sections[1].Name != ".text" {
+		t.Errorf("sections = %+v", sections)
+	}
+}
+
+func TestParseOverlappingTables(t *testing.T) {
+	data := buildELF(binary.LittleEndian, nil, []testSection{
+		{name: ".text", typ: 1, flags: SHF_ALLOC | SHF_EXECINSTR, data: []byte{0xc3}},
+		{name: ".data", typ: 1, flags: SHF_WRITE | SHF_ALLOC, data: []byte("data")},
+	})
+	order := binary.LittleEndian
+	ehdr, _, err := ReadELFHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A program header table inside the ELF header, and .data placed
+	// over .text
+	order.PutUint64(data[32:], 8)
+	order.PutUint16(data[56:], 1)
+	shdr := func(i uint64) []byte { return data[ehdr.Shoff+i*uint64(ehdr.Shentsize):] }
+	order.PutUint64(shdr(2)[24:], order.Uint64(shdr(1)[24:]))
+
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if phdrs, _ := f.ProgramHeaders(); len(phdrs) != 1 {
+		t.Errorf("read %d program headers, want 1", len(phdrs))
+	}
+	problems, err := CheckLayout(f, f.Ehdr, f.Order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(problems[0], "[1] .text") || !strings.Contains(problems[0], "[2] .data") {
+		t.Errorf("problems = %q, want .text and .data to overlap", problems)
+	}
+}
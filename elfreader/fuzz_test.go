@@ -0,0 +1,76 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"regexp"
+	"testing"
+)
+
+// fuzzSeed returns a small file with a loadable segment, code and a symbol
+// table, so that fuzzing starts out reaching every table reader
+func fuzzSeed(order binary.ByteOrder) []byte {
+	return buildELF(order, []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R | PF_X, Vaddr: 0x400000, Paddr: 0x400000, Filesz: 0x100, Memsz: 0x100, Align: 0x1000},
+		{Type: PT_NOTE, Flags: PF_R, Offset: 0x40, Vaddr: 0x400040, Filesz: 0x10, Memsz: 0x10, Align: 4},
+	}, []testSection{
+		{name: ".text", typ: 1, flags: SHF_ALLOC | SHF_EXECINSTR, addr: testEntry, data: []byte{0x31, 0xc0, 0xc3}},
+		{name: ".strtab", typ: SHT_STRTAB, data: []byte("\x00main\x00")},
+		{name: ".symtab", typ: SHT_SYMTAB, link: 2, info: 1, entsize: 24, data: symbolData(order, []Elf64Sym{
+			{},
+			{Name: 1, Info: STB_GLOBAL<<4 | STT_FUNC, Shndx: 1, Value: testEntry, Size: 3},
+		})},
+	})
+}
+
+// archiveMember encodes an ar member header followed by the contents
+func archiveMember(name, contents string) string {
+	return fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", name, 0, 0, 0, 0644, len(contents)) + contents
+}
+
+// FuzzParseELF checks that no input makes the header parsing or the table
+// readers panic. Run it with go test -fuzz=FuzzParseELF ./elfreader.
+func FuzzParseELF(f *testing.F) {
+	f.Add(fuzzSeed(binary.LittleEndian))
+	f.Add(fuzzSeed(binary.BigEndian))
+	f.Add(buildELF(binary.LittleEndian, nil, nil))
+	f.Add([]byte(ARCHIVE_MAGIC + archiveMember("//", "main.o/\n") + archiveMember("/0", "\x7fELF")))
+
+	all := regexp.MustCompile(".")
+	f.Fuzz(func(t *testing.T, data []byte) {
+		ReadArchive(bytes.NewReader(data))
+
+		file, err := Parse(data)
+		if err != nil {
+			return
+		}
+		ehdr, order := file.Ehdr, file.Order
+
+		// Errors are expected on malformed input; only panics fail
+		Validate(file, ehdr, order)
+		CheckLayout(file, ehdr, order)
+		ReadSymbolTables(file, ehdr, order)
+		FindSymbols(file, ehdr, order, all)
+		ReadDynamic(file, ehdr, order)
+		ReadDependencies(file, ehdr, order)
+		ReadRelocations(file, ehdr, order)
+		ReadNotes(file, ehdr, order)
+		ReadHashTables(file, ehdr, order)
+		ReadVersionInfo(file, ehdr, order)
+		ReadEHFrameHdr(file, ehdr, order)
+		ReadSummary(file, ehdr, order)
+		ReadAddresses(file, ehdr, order)
+		ReadCounts(file, ehdr, order)
+		ReadMemoryMap(file, ehdr, order)
+		ReadDebugLink(file, ehdr, order)
+		ReadEntryBytes(file, ehdr, order, 16)
+		LookupAddress(file, ehdr, order, ehdr.Entry)
+		LookupOffset(file, ehdr, order, ehdr.Phoff)
+
+		shdrwns, _ := file.Sections()
+		for _, shdrwn := range shdrwns {
+			ReadSectionContents(file, ehdr, order, shdrwn)
+		}
+	})
+}
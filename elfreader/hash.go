@@ -0,0 +1,234 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// HashTable is the decoded structure of a SysV (SHT_HASH) or GNU
+// (SHT_GNU_HASH) symbol hash table. ChainLengths holds the number of symbols
+// reachable from each bucket. For GNU tables Nchain is the number of chain
+// entries, known only when the linked dynamic symbol table is available.
+type HashTable struct {
+	Section      string   `json:"Section" yaml:"Section"`
+	Offset       uint64   `json:"Offset" yaml:"Offset"`
+	GNU          bool     `json:"GNU" yaml:"GNU"`
+	Nbucket      uint32   `json:"Nbucket" yaml:"Nbucket"`
+	Nchain       uint32   `json:"Nchain" yaml:"Nchain"`
+	Symoffset    uint32   `json:"Symoffset" yaml:"Symoffset"`
+	BloomSize    uint32   `json:"BloomSize" yaml:"BloomSize"`
+	BloomShift   uint32   `json:"BloomShift" yaml:"BloomShift"`
+	ChainLengths []uint32 `json:"ChainLengths" yaml:"ChainLengths"`
+}
+
+// Histogram returns the number of buckets for each chain length, indexed by
+// the length
+func (h *HashTable) Histogram() []uint32 {
+	var counts []uint32
+	for _, length := range h.ChainLengths {
+		for uint32(len(counts)) <= length {
+			counts = append(counts, 0)
+		}
+		counts[length]++
+	}
+	return counts
+}
+
+// errHashTruncated reports a hash table whose buckets or chains run past
+// the data that holds it
+var errHashTruncated = errors.New("hash table is truncated")
+
+// errHashLoop reports hash chains that visit more symbols than the table
+// holds, because a chain loops or several buckets share one chain
+var errHashLoop = errors.New("hash chain loops")
+
+// hashWord returns the 32-bit word at index i of data
+func hashWord(data []byte, order binary.ByteOrder, i uint64) (uint32, error) {
+	if i*4+4 > uint64(len(data)) {
+		return 0, errHashTruncated
+	}
+	return order.Uint32(data[i*4:]), nil
+}
+
+// parseSysvHash decodes a SysV hash table: nbucket and nchain followed by
+// the bucket and chain arrays. Each chain is followed through the chain
+// array until it reaches STN_UNDEF.
+func parseSysvHash(data []byte, order binary.ByteOrder) (HashTable, error) {
+	var h HashTable
+	if len(data) < 8 {
+		return h, errHashTruncated
+	}
+	h.Nbucket = order.Uint32(data)
+	h.Nchain = order.Uint32(data[4:])
+	if 2+uint64(h.Nbucket)+uint64(h.Nchain) > uint64(len(data))/4 {
+		return h, errHashTruncated
+	}
+
+	// Every symbol sits on exactly one chain, so the chains together hold at
+	// most nchain entries. Counting the steps of all walks against that
+	// bounds the work by nchain even when chains loop or run into each other.
+	var steps uint32
+	h.ChainLengths = make([]uint32, h.Nbucket)
+	for b := range h.ChainLengths {
+		index, _ := hashWord(data, order, 2+uint64(b))
+		for index != 0 && index < h.Nchain {
+			if steps == h.Nchain {
+				return h, errHashLoop
+			}
+			steps++
+			h.ChainLengths[b]++
+			index, _ = hashWord(data, order, 2+uint64(h.Nbucket)+uint64(index))
+		}
+	}
+	return h, nil
+}
+
+// parseGNUHash decodes a GNU hash table: nbuckets, symoffset, bloom_size and
+// bloom_shift, the bloom filter of wordSize-byte words, the buckets and the
+// chain array. Each bucket holds the first symbol index of its chain, and
+// the last hash value of a chain has its low bit set. nsyms is the size of
+// the dynamic symbol table, or 0 when it is unknown.
+func parseGNUHash(data []byte, order binary.ByteOrder, wordSize uint64, nsyms uint64) (HashTable, error) {
+	h := HashTable{GNU: true}
+	if len(data) < 16 {
+		return h, errHashTruncated
+	}
+	h.Nbucket = order.Uint32(data)
+	h.Symoffset = order.Uint32(data[4:])
+	h.BloomSize = order.Uint32(data[8:])
+	h.BloomShift = order.Uint32(data[12:])
+
+	bucketStart := 4 + uint64(h.BloomSize)*wordSize/4
+	chainStart := bucketStart + uint64(h.Nbucket)
+	if chainStart > uint64(len(data))/4 {
+		return h, errHashTruncated
+	}
+	if nsyms > uint64(h.Symoffset) {
+		h.Nchain = uint32(nsyms - uint64(h.Symoffset))
+	}
+
+	// As with SysV tables the chains do not share entries, so all walks
+	// together cannot take more steps than there are chain words
+	steps, maxSteps := uint64(0), uint64(len(data))/4-chainStart
+	h.ChainLengths = make([]uint32, h.Nbucket)
+	for b := range h.ChainLengths {
+		index, _ := hashWord(data, order, bucketStart+uint64(b))
+		if index < h.Symoffset || index == 0 {
+			continue
+		}
+		for i := uint64(index - h.Symoffset); ; i++ {
+			if h.Nchain != 0 && i >= uint64(h.Nchain) {
+				return h, fmt.Errorf("chain of bucket %d runs past the dynamic symbol table", b)
+			}
+			hash, err := hashWord(data, order, chainStart+i)
+			if err != nil {
+				return h, err
+			}
+			if steps == maxSteps {
+				return h, errHashLoop
+			}
+			steps++
+			h.ChainLengths[b]++
+			if hash&1 != 0 {
+				break
+			}
+		}
+	}
+	return h, nil
+}
+
+// parseHashTable decodes the data of a SysV or GNU hash table
+func parseHashTable(data []byte, ehdr *Elf64Ehdr, order binary.ByteOrder, gnu bool, nsyms uint64) (HashTable, error) {
+	if !gnu {
+		return parseSysvHash(data, order)
+	}
+	wordSize := uint64(8)
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		wordSize = 4
+	}
+	return parseGNUHash(data, order, wordSize, nsyms)
+}
+
+// ReadHashTables decodes every SHT_HASH and SHT_GNU_HASH section. Files
+// without section headers are searched through the DT_HASH and DT_GNU_HASH
+// entries of the dynamic array instead.
+func ReadHashTables(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]HashTable, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []HashTable
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Type != SHT_HASH && shdrwn.Type != SHT_GNU_HASH {
+			continue
+		}
+		data, err := ReadSectionData(file, shdrwn)
+		if err != nil {
+			return nil, err
+		}
+		// sh_link names the dynamic symbol table the table indexes
+		var nsyms uint64
+		if link := int(shdrwn.Link); link != 0 && link < len(shdrwns) {
+			entsize := shdrwns[link].Entsize
+			if entsize == 0 {
+				entsize = symbolEntrySize(ehdr)
+			}
+			nsyms = shdrwns[link].Size / entsize
+		}
+		table, err := parseHashTable(data, ehdr, order, shdrwn.Type == SHT_GNU_HASH, nsyms)
+		if err != nil {
+			return nil, fmt.Errorf("section '%s': %w", shdrwn.Name, err)
+		}
+		table.Section = shdrwn.Name
+		table.Offset = shdrwn.Offset
+		tables = append(tables, table)
+	}
+	if len(shdrwns) != 0 {
+		return tables, nil
+	}
+
+	dynamic, err := ReadDynamic(file, ehdr, order)
+	if err != nil || dynamic == nil {
+		return nil, err
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	for _, dyn := range dynamic.Entries {
+		if dyn.Tag != DT_HASH && dyn.Tag != DT_GNU_HASH {
+			continue
+		}
+		// The table size is not recorded, so read up to the end of the
+		// segment that maps it
+		offset, size, found := segmentRemainder(phdrs, dyn.Val)
+		if !found {
+			continue
+		}
+		data, err := ReadBytes(file, offset, size)
+		if err != nil {
+			return nil, fmt.Errorf("reading hash table at offset 0x%x: %w", offset, err)
+		}
+		table, err := parseHashTable(data, ehdr, order, dyn.Tag == DT_GNU_HASH, 0)
+		if err != nil {
+			return nil, fmt.Errorf("hash table at offset 0x%x: %w", offset, err)
+		}
+		table.Offset = offset
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
+
+// segmentRemainder translates a virtual address into a file offset and the
+// number of file bytes left in the PT_LOAD segment that maps it
+func segmentRemainder(phdrs []Elf64Phdr, vaddr uint64) (uint64, uint64, bool) {
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_LOAD && vaddr >= phdr.Vaddr && vaddr-phdr.Vaddr < phdr.Filesz {
+			return vaddr - phdr.Vaddr + phdr.Offset, phdr.Filesz - (vaddr - phdr.Vaddr), true
+		}
+	}
+	return 0, 0, false
+}
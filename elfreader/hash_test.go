@@ -0,0 +1,107 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// hashWords encodes the 32-bit words of a hash table
+func hashWords(order binary.ByteOrder, words ...uint32) []byte {
+	data := make([]byte, 4*len(words))
+	for i, word := range words {
+		order.PutUint32(data[4*i:], word)
+	}
+	return data
+}
+
+// readHashTable builds a file holding one hash table section and decodes it
+func readHashTable(t *testing.T, typ uint32, data []byte) (HashTable, error) {
+	t.Helper()
+	f, err := Parse(buildELF(binary.LittleEndian, nil, []testSection{
+		{name: ".hash", typ: typ, flags: SHF_ALLOC, entsize: 4, data: data},
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables, err := ReadHashTables(f, f.Ehdr, f.Order)
+	if err != nil {
+		return HashTable{}, err
+	}
+	if len(tables) != 1 {
+		t.Fatalf("read %d hash tables, want 1", len(tables))
+	}
+	return tables[0], nil
+}
+
+func TestReadHashTables(t *testing.T) {
+	order := binary.LittleEndian
+	tests := []struct {
+		name    string
+		typ     uint32
+		data    []byte
+		lengths []uint32
+	}{
+		// Bucket 0 holds symbols 1 and 2, bucket 1 symbol 3
+		{"sysv", SHT_HASH, hashWords(order, 2, 4, 1, 3, 0, 2, 0, 0), []uint32{2, 1}},
+		// Symbols 1 and 2 in bucket 0, symbol 3 in bucket 1
+		{"gnu", SHT_GNU_HASH, hashWords(order, 2, 1, 0, 0, 1, 3, 0x10, 0x21, 0x31), []uint32{2, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			table, err := readHashTable(t, tt.typ, tt.data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(table.ChainLengths) != len(tt.lengths) {
+				t.Fatalf("chain lengths = %v, want %v", table.ChainLengths, tt.lengths)
+			}
+			for i := range tt.lengths {
+				if table.ChainLengths[i] != tt.lengths[i] {
+					t.Errorf("chain lengths = %v, want %v", table.ChainLengths, tt.lengths)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestReadHashTablesChainLoops(t *testing.T) {
+	order := binary.LittleEndian
+
+	// A long SysV chain that every bucket starts on: walking it once per
+	// bucket would take nbucket*nchain steps
+	const nbucket, nchain = 1 << 16, 1 << 16
+	shared := []uint32{nbucket, nchain}
+	for b := 0; b < nbucket; b++ {
+		shared = append(shared, 1)
+	}
+	// chain[i] = i+1, with chain[0] and the last entry STN_UNDEF
+	for i := uint32(0); i < nchain; i++ {
+		next := i + 1
+		if i == 0 || next == nchain {
+			next = 0
+		}
+		shared = append(shared, next)
+	}
+
+	tests := []struct {
+		name string
+		typ  uint32
+		data []byte
+	}{
+		// chain[1] = 2 and chain[2] = 1
+		{"sysv cycle", SHT_HASH, hashWords(order, 1, 3, 1, 0, 2, 1)},
+		{"sysv shared chain", SHT_HASH, hashWords(order, shared...)},
+		// Both buckets start on the same two-symbol chain
+		{"gnu shared chain", SHT_GNU_HASH, hashWords(order, 2, 1, 0, 0, 1, 1, 0x10, 0x21)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := readHashTable(t, tt.typ, tt.data)
+			if !errors.Is(err, errHashLoop) {
+				t.Errorf("error = %v, want %v", err, errHashLoop)
+			}
+		})
+	}
+}
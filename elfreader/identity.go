@@ -0,0 +1,117 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Identity is a short fingerprint of a binary, useful for matching it with
+// its separate debug information
+type Identity struct {
+	BuildID  string `json:"BuildID" yaml:"BuildID"`
+	Soname   string `json:"Soname" yaml:"Soname"`
+	Class    string `json:"Class" yaml:"Class"`
+	Machine  string `json:"Machine" yaml:"Machine"`
+	Type     string `json:"Type" yaml:"Type"`
+	PIE      bool   `json:"PIE" yaml:"PIE"`
+	Stripped bool   `json:"Stripped" yaml:"Stripped"`
+}
+
+// IsPIE reports whether the file is a position-independent executable: an
+// ET_DYN file that, unlike a shared library, requests an interpreter
+func IsPIE(ehdr *Elf64Ehdr, phdrs []Elf64Phdr) bool {
+	if ehdr.Type != ET_DYN {
+		return false
+	}
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_INTERP {
+			return true
+		}
+	}
+	return false
+}
+
+// ReadIdentity gathers the build ID note, the DT_SONAME, the header
+// description and whether the file is a PIE executable or stripped. A
+// stripped file has no SHT_SYMTAB.
+func ReadIdentity(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*Identity, error) {
+	id := &Identity{
+		Class:   ClassName(ehdr.Ident[EI_CLASS]),
+		Machine: MachineName(ehdr.Machine),
+	}
+
+	groups, err := ReadNotes(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		for _, note := range group.Notes {
+			if note.Owner == "GNU" && note.Type == NT_GNU_BUILD_ID {
+				id.BuildID = fmt.Sprintf("%x", note.Desc)
+			}
+		}
+	}
+
+	dynamic, err := ReadDynamic(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	if dynamic != nil {
+		for _, dyn := range dynamic.Entries {
+			if dyn.Tag == DT_SONAME {
+				id.Soname = GetString(dynamic.Strtab, uint32(dyn.Val))
+			}
+		}
+	}
+
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	id.Type = FileTypeName(ehdr, phdrs)
+	id.PIE = IsPIE(ehdr, phdrs)
+
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	id.Stripped = true
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Type == SHT_SYMTAB {
+			id.Stripped = false
+		}
+	}
+	return id, nil
+}
+
+// DebugLink names the separate debug file of a binary and the CRC32 of its
+// contents, as stored in .gnu_debuglink
+type DebugLink struct {
+	File string `json:"File" yaml:"File"`
+	CRC  uint32 `json:"CRC" yaml:"CRC"`
+}
+
+// ReadDebugLink decodes the .gnu_debuglink section: a NUL-terminated file
+// name padded to a 4-byte boundary, followed by the CRC32. It returns nil
+// for files without the section.
+func ReadDebugLink(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*DebugLink, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	shdrwn, ok := FindSection(shdrwns, ".gnu_debuglink")
+	if !ok {
+		return nil, nil
+	}
+	data, err := ReadSectionData(file, shdrwn)
+	if err != nil {
+		return nil, err
+	}
+	name := GetString(data, 0)
+	crcOffset := (len(name) + 1 + 3) &^ 3
+	if crcOffset+4 > len(data) {
+		return nil, fmt.Errorf("section '%s' is too short to hold a CRC", shdrwn.Name)
+	}
+	return &DebugLink{File: name, CRC: order.Uint32(data[crcOffset:])}, nil
+}
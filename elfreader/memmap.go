@@ -0,0 +1,139 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"io"
+	"sort"
+)
+
+// MappedSegment is where one PT_LOAD segment lands in memory and the file
+// range it is loaded from. ZeroFill counts the bytes past the file data
+// that are cleared at load time, as for .bss.
+type MappedSegment struct {
+	Index     int    `json:"Index" yaml:"Index"`
+	Start     uint64 `json:"Start" yaml:"Start"`
+	End       uint64 `json:"End" yaml:"End"`
+	Flags     uint32 `json:"Flags" yaml:"Flags"`
+	FileStart uint64 `json:"FileStart" yaml:"FileStart"`
+	FileEnd   uint64 `json:"FileEnd" yaml:"FileEnd"`
+	ZeroFill  uint64 `json:"ZeroFill" yaml:"ZeroFill"`
+}
+
+// ReadMemoryMap returns the PT_LOAD segments sorted by virtual address
+func ReadMemoryMap(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]MappedSegment, error) {
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	segments := []MappedSegment{}
+	for i, phdr := range phdrs {
+		if phdr.Type != PT_LOAD {
+			continue
+		}
+		// Ranges running past the top of memory or of the file are cut
+		// short there rather than wrapping around
+		segment := MappedSegment{
+			Index:     i,
+			Start:     phdr.Vaddr,
+			End:       newFileRange(phdr.Vaddr, phdr.Memsz).end,
+			Flags:     phdr.Flags,
+			FileStart: phdr.Offset,
+			FileEnd:   newFileRange(phdr.Offset, phdr.Filesz).end,
+		}
+		if phdr.Memsz > phdr.Filesz {
+			segment.ZeroFill = phdr.Memsz - phdr.Filesz
+		}
+		segments = append(segments, segment)
+	}
+	sort.SliceStable(segments, func(i, j int) bool { return segments[i].Start < segments[j].Start })
+	return segments, nil
+}
+
+// AddressMatch is a section or segment that contains an address. Name is
+// the section name or the segment type, and Delta is how far into it the
+// address lies.
+type AddressMatch struct {
+	Index int    `json:"Index" yaml:"Index"`
+	Name  string `json:"Name" yaml:"Name"`
+	Start uint64 `json:"Start" yaml:"Start"`
+	Delta uint64 `json:"Delta" yaml:"Delta"`
+}
+
+// AddressLookup lists the sections and segments containing an address.
+// Both lists are empty when the address is not mapped.
+type AddressLookup struct {
+	Address  uint64         `json:"Address" yaml:"Address"`
+	Sections []AddressMatch `json:"Sections" yaml:"Sections"`
+	Segments []AddressMatch `json:"Segments" yaml:"Segments"`
+}
+
+// LookupAddress finds the allocated sections whose [Addr, Addr+Size) and
+// the segments whose [Vaddr, Vaddr+Memsz) contain the virtual address
+func LookupAddress(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, addr uint64) (*AddressLookup, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &AddressLookup{Address: addr, Sections: []AddressMatch{}, Segments: []AddressMatch{}}
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Flags&SHF_ALLOC != 0 && addr >= shdrwn.Addr && addr-shdrwn.Addr < shdrwn.Size {
+			lookup.Sections = append(lookup.Sections, AddressMatch{shdrwn.Index, shdrwn.Name, shdrwn.Addr, addr - shdrwn.Addr})
+		}
+	}
+	for i, phdr := range phdrs {
+		if addr >= phdr.Vaddr && addr-phdr.Vaddr < phdr.Memsz {
+			lookup.Segments = append(lookup.Segments, AddressMatch{i, PhdrTypeName(phdr.Type), phdr.Vaddr, addr - phdr.Vaddr})
+		}
+	}
+	return lookup, nil
+}
+
+// OffsetLookup is where a file offset is loaded. Mapped is false when no
+// PT_LOAD segment loads the offset, leaving Vaddr unset. Sections lists the
+// sections holding the offset, with Start being their file offset; Segments
+// lists the PT_LOAD segments loading it.
+type OffsetLookup struct {
+	Offset   uint64         `json:"Offset" yaml:"Offset"`
+	Vaddr    uint64         `json:"Vaddr" yaml:"Vaddr"`
+	Mapped   bool           `json:"Mapped" yaml:"Mapped"`
+	Sections []AddressMatch `json:"Sections" yaml:"Sections"`
+	Segments []AddressMatch `json:"Segments" yaml:"Segments"`
+}
+
+// LookupOffset finds the virtual address a file offset is loaded at, as
+// offset - p_offset + p_vaddr of the PT_LOAD segment holding it, and the
+// sections the offset falls in
+func LookupOffset(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, offset uint64) (*OffsetLookup, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	lookup := &OffsetLookup{Offset: offset, Sections: []AddressMatch{}, Segments: []AddressMatch{}}
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Type != SHT_NULL && shdrwn.Type != SHT_NOBITS && offset >= shdrwn.Offset && offset-shdrwn.Offset < shdrwn.Size {
+			lookup.Sections = append(lookup.Sections, AddressMatch{shdrwn.Index, shdrwn.Name, shdrwn.Offset, offset - shdrwn.Offset})
+		}
+	}
+	for i, phdr := range phdrs {
+		if phdr.Type != PT_LOAD || offset < phdr.Offset || offset-phdr.Offset >= phdr.Filesz {
+			continue
+		}
+		lookup.Segments = append(lookup.Segments, AddressMatch{i, PhdrTypeName(phdr.Type), phdr.Offset, offset - phdr.Offset})
+		if !lookup.Mapped {
+			lookup.Vaddr = offset - phdr.Offset + phdr.Vaddr
+			lookup.Mapped = true
+		}
+	}
+	return lookup, nil
+}
@@ -0,0 +1,31 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestReadMemoryMapAtTheTop(t *testing.T) {
+	const top = 1<<64 - 1
+	data := buildELF(binary.LittleEndian, []Elf64Phdr{
+		{Type: PT_LOAD, Flags: PF_R | PF_W, Offset: top - 0xf, Vaddr: top - 0xfff, Filesz: 0x20, Memsz: 0x2000},
+		{Type: PT_LOAD, Flags: PF_R | PF_X, Vaddr: 0x400000, Filesz: 0x100, Memsz: 0x100},
+	}, nil)
+	ehdr, order, err := ReadELFHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	segments, err := ReadMemoryMap(bytes.NewReader(data), ehdr, order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []MappedSegment{
+		{Index: 1, Start: 0x400000, End: 0x400100, Flags: PF_R | PF_X, FileStart: 0, FileEnd: 0x100},
+		{Index: 0, Start: top - 0xfff, End: top, Flags: PF_R | PF_W, FileStart: top - 0xf, FileEnd: top, ZeroFill: 0x1fe0},
+	}
+	if len(segments) != len(want) || segments[0] != want[0] || segments[1] != want[1] {
+		t.Errorf("memory map = %+v, want %+v", segments, want)
+	}
+}
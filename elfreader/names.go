@@ -0,0 +1,314 @@
+package elfreader
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// machineNames maps e_machine values to the descriptions used by readelf
+var machineNames = map[uint16]string{
+	0:   "None",
+	2:   "Sparc",
+	3:   "Intel 80386",
+	4:   "MC68000",
+	7:   "Intel 80860",
+	8:   "MIPS R3000",
+	20:  "PowerPC",
+	21:  "PowerPC64",
+	22:  "IBM S/390",
+	40:  "ARM",
+	42:  "Renesas / SuperH SH",
+	43:  "Sparc v9",
+	50:  "Intel IA-64",
+	62:  "Advanced Micro Devices X86-64",
+	183: "AArch64",
+	243: "RISC-V",
+	247: "Linux BPF",
+	258: "LoongArch",
+}
+
+// MachineName returns the readelf-style name of an e_machine value
+func MachineName(m uint16) string {
+	if name, ok := machineNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", m)
+}
+
+// TypeName returns the readelf-style description of an e_type value
+func TypeName(t uint16) string {
+	switch {
+	case t == 0:
+		return "NONE (None)"
+	case t == 1:
+		return "REL (Relocatable file)"
+	case t == 2:
+		return "EXEC (Executable file)"
+	case t == 3:
+		return "DYN (Shared object file)"
+	case t == 4:
+		return "CORE (Core file)"
+	case t >= 0xfe00 && t <= 0xfeff:
+		return fmt.Sprintf("OS Specific: (0x%x)", t)
+	case t >= 0xff00:
+		return fmt.Sprintf("Processor Specific: (0x%x)", t)
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", t)
+}
+
+// FileTypeName is TypeName with ET_DYN files told apart: position-independent
+// executables are described as such rather than as shared objects
+func FileTypeName(ehdr *Elf64Ehdr, phdrs []Elf64Phdr) string {
+	if IsPIE(ehdr, phdrs) {
+		return "DYN (Position-Independent Executable file)"
+	}
+	return TypeName(ehdr.Type)
+}
+
+// phdrTypeNames maps p_type values to the names used by readelf
+var phdrTypeNames = map[uint32]string{
+	0:          "NULL",
+	1:          "LOAD",
+	2:          "DYNAMIC",
+	3:          "INTERP",
+	4:          "NOTE",
+	5:          "SHLIB",
+	6:          "PHDR",
+	7:          "TLS",
+	0x6474e550: "GNU_EH_FRAME",
+	0x6474e551: "GNU_STACK",
+	0x6474e552: "GNU_RELRO",
+	0x6474e553: "GNU_PROPERTY",
+}
+
+// PhdrTypeName returns the readelf-style name of a p_type value
+func PhdrTypeName(t uint32) string {
+	if name, ok := phdrTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", t)
+}
+
+// symBindNames maps STB_* values to the names used by readelf
+var symBindNames = map[uint8]string{
+	STB_LOCAL:      "LOCAL",
+	STB_GLOBAL:     "GLOBAL",
+	STB_WEAK:       "WEAK",
+	STB_GNU_UNIQUE: "UNIQUE",
+}
+
+// SymBindName returns the readelf-style name of a symbol binding
+func SymBindName(bind uint8) string {
+	if name, ok := symBindNames[bind]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown>: %d", bind)
+}
+
+// symTypeNames maps STT_* values to the names used by readelf
+var symTypeNames = map[uint8]string{
+	STT_NOTYPE:    "NOTYPE",
+	STT_OBJECT:    "OBJECT",
+	STT_FUNC:      "FUNC",
+	STT_SECTION:   "SECTION",
+	STT_FILE:      "FILE",
+	STT_COMMON:    "COMMON",
+	STT_TLS:       "TLS",
+	STT_GNU_IFUNC: "IFUNC",
+}
+
+// SymTypeName returns the readelf-style name of a symbol type
+func SymTypeName(t uint8) string {
+	if name, ok := symTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown>: %d", t)
+}
+
+// SymVisibilityName returns the readelf-style name of a symbol visibility
+func SymVisibilityName(v uint8) string {
+	switch v {
+	case STV_DEFAULT:
+		return "DEFAULT"
+	case STV_INTERNAL:
+		return "INTERNAL"
+	case STV_HIDDEN:
+		return "HIDDEN"
+	case STV_PROTECTED:
+		return "PROTECTED"
+	}
+	return fmt.Sprintf("<unknown>: %d", v)
+}
+
+// SectionIndexName returns the readelf-style name of a symbol's st_shndx:
+// UND, ABS and COM for the special indexes, or the index itself
+func SectionIndexName(shndx uint16) string {
+	switch {
+	case shndx == SHN_UNDEF:
+		return "UND"
+	case shndx == SHN_ABS:
+		return "ABS"
+	case shndx == SHN_COMMON:
+		return "COM"
+	case shndx == SHN_XINDEX:
+		return "XIDX"
+	case shndx >= SHN_LOPROC && shndx <= SHN_HIPROC:
+		return fmt.Sprintf("PRC[0x%04x]", shndx)
+	case shndx >= SHN_LOOS && shndx <= SHN_HIOS:
+		return fmt.Sprintf("OS [0x%04x]", shndx)
+	case shndx >= SHN_LORESERVE:
+		return fmt.Sprintf("RSV[0x%04x]", shndx)
+	}
+	return strconv.Itoa(int(shndx))
+}
+
+// ParsePhdrType parses a segment type given either as a readelf-style name,
+// with or without the PT_ prefix, or as a number
+func ParsePhdrType(s string) (uint32, error) {
+	name := strings.TrimPrefix(strings.ToUpper(s), "PT_")
+	for t, typeName := range phdrTypeNames {
+		if typeName == name {
+			return t, nil
+		}
+	}
+	t, err := strconv.ParseUint(s, 0, 32)
+	if err != nil {
+		return 0, fmt.Errorf("unknown segment type %q", s)
+	}
+	return uint32(t), nil
+}
+
+// PhdrFlagsString renders p_flags as readelf's three-character Flg column
+func PhdrFlagsString(f uint32) string {
+	flags := []byte("   ")
+	if f&PF_R != 0 {
+		flags[0] = 'R'
+	}
+	if f&PF_W != 0 {
+		flags[1] = 'W'
+	}
+	if f&PF_X != 0 {
+		flags[2] = 'E'
+	}
+	return string(flags)
+}
+
+// sectionFlagLetters lists the SHF_* flags in the order readelf prints them
+var sectionFlagLetters = []struct {
+	flag   uint64
+	letter byte
+}{
+	{SHF_WRITE, 'W'},
+	{SHF_ALLOC, 'A'},
+	{SHF_EXECINSTR, 'X'},
+	{SHF_MERGE, 'M'},
+	{SHF_STRINGS, 'S'},
+	{SHF_INFO_LINK, 'I'},
+	{SHF_LINK_ORDER, 'L'},
+	{SHF_OS_NONCONFORMING, 'O'},
+	{SHF_GROUP, 'G'},
+	{SHF_TLS, 'T'},
+	{SHF_COMPRESSED, 'C'},
+	{SHF_EXCLUDE, 'E'},
+}
+
+// SectionFlagsString renders sh_flags as readelf's compact letter field
+func SectionFlagsString(f uint64) string {
+	var letters []byte
+	for _, fl := range sectionFlagLetters {
+		if f&fl.flag != 0 {
+			letters = append(letters, fl.letter)
+		}
+	}
+	return string(letters)
+}
+
+// sectionTypeNames maps sh_type values to the names used by readelf
+var sectionTypeNames = map[uint32]string{
+	0:          "NULL",
+	1:          "PROGBITS",
+	2:          "SYMTAB",
+	3:          "STRTAB",
+	4:          "RELA",
+	5:          "HASH",
+	6:          "DYNAMIC",
+	7:          "NOTE",
+	8:          "NOBITS",
+	9:          "REL",
+	10:         "SHLIB",
+	11:         "DYNSYM",
+	14:         "INIT_ARRAY",
+	15:         "FINI_ARRAY",
+	16:         "PREINIT_ARRAY",
+	17:         "GROUP",
+	18:         "SYMTAB SECTION INDICES",
+	19:         "RELR",
+	0x6ffffff5: "GNU_ATTRIBUTES",
+	0x6ffffff6: "GNU_HASH",
+	0x6ffffff7: "GNU_LIBLIST",
+	0x6ffffffd: "VERDEF",
+	0x6ffffffe: "VERNEED",
+	0x6fffffff: "VERSYM",
+}
+
+// SectionTypeName returns the readelf-style name of a sh_type value
+func SectionTypeName(t uint32) string {
+	if name, ok := sectionTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", t)
+}
+
+// osabiNames maps EI_OSABI values to the names used by readelf
+var osabiNames = map[byte]string{
+	0:   "UNIX - System V",
+	1:   "UNIX - HP-UX",
+	2:   "UNIX - NetBSD",
+	3:   "UNIX - GNU",
+	6:   "UNIX - Solaris",
+	7:   "UNIX - AIX",
+	8:   "UNIX - IRIX",
+	9:   "UNIX - FreeBSD",
+	10:  "UNIX - TRU64",
+	11:  "Novell - Modesto",
+	12:  "UNIX - OpenBSD",
+	13:  "VMS - OpenVMS",
+	14:  "HP - Non-Stop Kernel",
+	15:  "AROS",
+	16:  "FenixOS",
+	17:  "Nuxi CloudABI",
+	18:  "Stratus Technologies OpenVOS",
+	97:  "ARM",
+	255: "Standalone App",
+}
+
+// OSABIName returns the readelf-style name of an EI_OSABI value
+func OSABIName(b byte) string {
+	if name, ok := osabiNames[b]; ok {
+		return name
+	}
+	return fmt.Sprintf("<unknown: 0x%x>", b)
+}
+
+// ClassName returns the readelf-style name of an EI_CLASS value
+func ClassName(b byte) string {
+	switch b {
+	case ELFCLASS32:
+		return "ELF32"
+	case ELFCLASS64:
+		return "ELF64"
+	}
+	return "<unknown>"
+}
+
+// DataEncodingName returns the readelf-style description of an EI_DATA value
+func DataEncodingName(b byte) string {
+	switch b {
+	case ELFDATA2LSB:
+		return "2's complement, little endian"
+	case ELFDATA2MSB:
+		return "2's complement, big endian"
+	}
+	return "<unknown>"
+}
@@ -0,0 +1,41 @@
+package elfreader
+
+import "testing"
+
+func TestPhdrFlagsString(t *testing.T) {
+	tests := []struct {
+		flags uint32
+		want  string
+	}{
+		{0, "   "},
+		{PF_R, "R  "},
+		{PF_R | PF_W, "RW "},
+		{PF_R | PF_X, "R E"},
+		{PF_R | PF_W | PF_X, "RWE"},
+	}
+	for _, tt := range tests {
+		if got := PhdrFlagsString(tt.flags); got != tt.want {
+			t.Errorf("PhdrFlagsString(0x%x) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
+
+func TestSectionFlagsString(t *testing.T) {
+	tests := []struct {
+		flags uint64
+		want  string
+	}{
+		{0, ""},
+		{SHF_WRITE | SHF_ALLOC, "WA"},
+		{SHF_ALLOC | SHF_EXECINSTR, "AX"},
+		{SHF_MERGE | SHF_STRINGS, "MS"},
+		{SHF_ALLOC | SHF_INFO_LINK, "AI"},
+		{SHF_WRITE | SHF_ALLOC | SHF_TLS, "WAT"},
+		{SHF_GROUP | SHF_LINK_ORDER, "LG"},
+	}
+	for _, tt := range tests {
+		if got := SectionFlagsString(tt.flags); got != tt.want {
+			t.Errorf("SectionFlagsString(0x%x) = %q, want %q", tt.flags, got, tt.want)
+		}
+	}
+}
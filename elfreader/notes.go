@@ -0,0 +1,135 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Note types for the "GNU" owner
+const (
+	NT_GNU_ABI_TAG         = 1
+	NT_GNU_HWCAP           = 2
+	NT_GNU_BUILD_ID        = 3
+	NT_GNU_GOLD_VERSION    = 4
+	NT_GNU_PROPERTY_TYPE_0 = 5
+)
+
+// Note is one entry of a note section or segment
+type Note struct {
+	Owner string
+	Type  uint32
+	Desc  []byte
+}
+
+// NoteGroup holds the notes found in one SHT_NOTE section or PT_NOTE segment
+type NoteGroup struct {
+	Name   string
+	Offset uint64
+	Notes  []Note
+}
+
+// gnuNoteTypeNames maps GNU note types to readelf's descriptions
+var gnuNoteTypeNames = map[uint32]string{
+	NT_GNU_ABI_TAG:         "NT_GNU_ABI_TAG (ABI version tag)",
+	NT_GNU_HWCAP:           "NT_GNU_HWCAP (DSO-supplied software HWCAP info)",
+	NT_GNU_BUILD_ID:        "NT_GNU_BUILD_ID (unique build ID bitstring)",
+	NT_GNU_GOLD_VERSION:    "NT_GNU_GOLD_VERSION (gold version)",
+	NT_GNU_PROPERTY_TYPE_0: "NT_GNU_PROPERTY_TYPE_0",
+}
+
+// NoteTypeName returns the readelf-style description of a note type
+func NoteTypeName(owner string, t uint32) string {
+	if owner == "GNU" {
+		if name, ok := gnuNoteTypeNames[t]; ok {
+			return name
+		}
+	}
+	return fmt.Sprintf("Unknown note type: (0x%08x)", t)
+}
+
+// alignUp rounds n up to a multiple of align
+func alignUp(n, align uint64) uint64 {
+	return (n + align - 1) &^ (align - 1)
+}
+
+// parseNotes splits raw note data into entries. Each entry is a namesz,
+// descsz and type header followed by the name and descriptor, both padded
+// to align bytes.
+func parseNotes(data []byte, order binary.ByteOrder, align uint64) ([]Note, error) {
+	if align != 8 {
+		align = 4
+	}
+
+	var notes []Note
+	for pos := uint64(0); pos+12 <= uint64(len(data)); {
+		namesz := uint64(order.Uint32(data[pos:]))
+		descsz := uint64(order.Uint32(data[pos+4:]))
+		noteType := order.Uint32(data[pos+8:])
+		pos += 12
+
+		nameEnd := pos + namesz
+		descStart := alignUp(nameEnd, align)
+		descEnd := descStart + descsz
+		if nameEnd > uint64(len(data)) || descEnd > uint64(len(data)) || descEnd < descStart {
+			return notes, fmt.Errorf("note at offset 0x%x runs past the end of its section", pos-12)
+		}
+
+		notes = append(notes, Note{
+			Owner: strings.TrimRight(string(data[pos:nameEnd]), "\x00"),
+			Type:  noteType,
+			Desc:  data[descStart:descEnd],
+		})
+		pos = alignUp(descEnd, align)
+	}
+	return notes, nil
+}
+
+// ReadNotes reads the notes of every SHT_NOTE section, or of every PT_NOTE
+// segment when the file has no section headers
+func ReadNotes(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]NoteGroup, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var groups []NoteGroup
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Type != SHT_NOTE {
+			continue
+		}
+		data, err := ReadSectionData(file, shdrwn)
+		if err != nil {
+			return nil, err
+		}
+		notes, err := parseNotes(data, order, shdrwn.Addralign)
+		if err != nil {
+			return nil, fmt.Errorf("section '%s': %w", shdrwn.Name, err)
+		}
+		groups = append(groups, NoteGroup{Name: shdrwn.Name, Offset: shdrwn.Offset, Notes: notes})
+	}
+	if len(shdrwns) != 0 {
+		return groups, nil
+	}
+
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	for i, phdr := range phdrs {
+		if phdr.Type != PT_NOTE {
+			continue
+		}
+		data, err := ReadBytes(file, phdr.Offset, phdr.Filesz)
+		if err != nil {
+			return nil, fmt.Errorf("reading note segment %d: %w", i, err)
+		}
+		notes, err := parseNotes(data, order, phdr.Align)
+		if err != nil {
+			return nil, fmt.Errorf("note segment %d: %w", i, err)
+		}
+		groups = append(groups, NoteGroup{Offset: phdr.Offset, Notes: notes})
+	}
+	return groups, nil
+}
@@ -0,0 +1,120 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// pastEnd reports whether any of the size bytes starting at offset lie
+// beyond the end of the file, by trying to read the last of them
+func pastEnd(file io.ReaderAt, offset, size uint64) (bool, error) {
+	end := offset + size
+	if end == offset {
+		return false, nil
+	}
+	if end < offset || end-1 > math.MaxInt64 {
+		return true, nil
+	}
+	var last [1]byte
+	_, err := file.ReadAt(last[:], int64(end-1))
+	if err == io.EOF {
+		return true, nil
+	}
+	return false, err
+}
+
+// checkTableBounds verifies that a table of count entries of entsize bytes
+// starting at offset lies entirely within the file. Each table is checked
+// and read at its own offset, so the header tables may come in any order,
+// and may even overlap the ELF header.
+func checkTableBounds(file io.ReaderAt, name string, offset, count, entsize uint64) error {
+	size := count * entsize
+	past, err := pastEnd(file, offset, size)
+	if err == nil && (past || entsize != 0 && size/entsize != count) {
+		err = fmt.Errorf("%s at offset 0x%x (%d entries of %d bytes) extends past the end of the file",
+			name, offset, count, entsize)
+	}
+	return err
+}
+
+// readerAt returns a reader positioned at offset, so that consecutive table
+// entries can be decoded with readStruct without any shared file position
+func readerAt(file io.ReaderAt, offset uint64) io.Reader {
+	if offset > math.MaxInt64 {
+		return io.NewSectionReader(file, 0, 0)
+	}
+	return io.NewSectionReader(file, int64(offset), math.MaxInt64-int64(offset))
+}
+
+// readStruct decodes data with binary.Read, reporting a read that runs off
+// the end of the file as io.ErrUnexpectedEOF
+func readStruct(r io.Reader, order binary.ByteOrder, data interface{}) error {
+	err := binary.Read(r, order, data)
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// ReadBytes reads size bytes starting at offset. The range is checked
+// against the file first, so that a corrupt size cannot make it allocate
+// more than the file holds.
+func ReadBytes(file io.ReaderAt, offset, size uint64) ([]byte, error) {
+	if past, err := pastEnd(file, offset, size); err != nil {
+		return nil, err
+	} else if past {
+		return nil, fmt.Errorf("%d bytes at offset 0x%x extend past the end of the file", size, offset)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(readerAt(file, offset), data); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return data, nil
+}
+
+// ReadStringTable reads the string table of size bytes at offset
+func ReadStringTable(file io.ReaderAt, offset, size uint64) ([]byte, error) {
+	if past, err := pastEnd(file, offset, size); err != nil {
+		return nil, err
+	} else if past {
+		return nil, fmt.Errorf("string table at offset 0x%x (%d bytes) extends past the end of the file", offset, size)
+	}
+	strData := make([]byte, size)
+	if _, err := io.ReadFull(readerAt(file, offset), strData); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, fmt.Errorf("reading string table at offset 0x%x: %w", offset, err)
+	}
+	return strData, nil
+}
+
+// GetString returns the NUL-terminated string at index, or "<corrupt>" when
+// the index lies outside the string table
+func GetString(data []byte, index uint32) string {
+	if uint64(index) >= uint64(len(data)) {
+		if index == 0 {
+			return ""
+		}
+		return "<corrupt>"
+	}
+	end := index
+	for end < uint32(len(data)) && data[end] != 0 {
+		end++
+	}
+	return string(data[index:end])
+}
+
+// ReadSectionData reads the file contents of a section
+func ReadSectionData(file io.ReaderAt, shdrwn Elf64ShdrWithName) ([]byte, error) {
+	data, err := ReadBytes(file, shdrwn.Offset, shdrwn.Size)
+	if err != nil {
+		return nil, fmt.Errorf("reading section '%s': %w", shdrwn.Name, err)
+	}
+	return data, nil
+}
@@ -0,0 +1,77 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestGetString(t *testing.T) {
+	table := []byte("\x00.text\x00.data\x00")
+	tests := []struct {
+		name  string
+		data  []byte
+		index uint32
+		want  string
+	}{
+		{"first", table, 1, ".text"},
+		{"tail of a name", table, 3, "ext"},
+		{"empty", table, 0, ""},
+		{"last byte", table, uint32(len(table) - 1), ""},
+		{"past the end", table, uint32(len(table)), "<corrupt>"},
+		{"far past the end", table, 0xffffffff, "<corrupt>"},
+		{"empty table", nil, 0, ""},
+		{"unterminated", []byte("\x00.text\x00.bss"), 7, ".bss"},
+	}
+	for _, tt := range tests {
+		if got := GetString(tt.data, tt.index); got != tt.want {
+			t.Errorf("%s: GetString(%q, %d) = %q, want %q", tt.name, tt.data, tt.index, got, tt.want)
+		}
+	}
+}
+
+func TestReadStringTableTooLarge(t *testing.T) {
+	data := []byte("\x00.text\x00")
+	tests := []struct {
+		offset, size uint64
+	}{
+		{0, 1 << 62},
+		{0, uint64(len(data)) + 1},
+		{4, 1<<64 - 1},
+		{1 << 62, 8},
+	}
+	for _, tt := range tests {
+		var before, after runtime.MemStats
+		runtime.ReadMemStats(&before)
+		_, err := ReadStringTable(bytes.NewReader(data), tt.offset, tt.size)
+		runtime.ReadMemStats(&after)
+		if err == nil {
+			t.Errorf("offset 0x%x size 0x%x: no error", tt.offset, tt.size)
+		}
+		if allocated := after.TotalAlloc - before.TotalAlloc; allocated > 1<<20 {
+			t.Errorf("offset 0x%x size 0x%x: allocated %d bytes", tt.offset, tt.size, allocated)
+		}
+	}
+
+	table, err := ReadStringTable(bytes.NewReader(data), 0, uint64(len(data)))
+	if err != nil || string(table) != string(data) {
+		t.Errorf("whole table = %q, %v", table, err)
+	}
+}
+
+func TestParseHugeSectionNameTable(t *testing.T) {
+	data := buildELF(binary.LittleEndian, nil, nil)
+	ehdr, _, err := ReadELFHeader(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	shstrtab := ehdr.Shoff + uint64(ehdr.Shstrndx)*uint64(ehdr.Shentsize)
+	binary.LittleEndian.PutUint64(data[shstrtab+32:], 1<<62)
+
+	_, err = Parse(data)
+	if err == nil || !strings.Contains(err.Error(), "extends past the end of the file") {
+		t.Errorf("error = %v, want the string table to extend past the end of the file", err)
+	}
+}
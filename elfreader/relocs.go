@@ -0,0 +1,149 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+type Elf64Rela struct {
+	Offset uint64
+	Info   uint64
+	Addend int64
+}
+
+type Elf64Rel struct {
+	Offset uint64
+	Info   uint64
+}
+
+type Elf32Rela struct {
+	Offset uint32
+	Info   uint32
+	Addend int32
+}
+
+type Elf32Rel struct {
+	Offset uint32
+	Info   uint32
+}
+
+// RelocationEntry is a relocation with r_info split and its symbol resolved
+type RelocationEntry struct {
+	Offset   uint64 `json:"Offset" yaml:"Offset"`
+	Info     uint64 `json:"Info" yaml:"Info"`
+	Type     uint32 `json:"Type" yaml:"Type"`
+	Addend   int64  `json:"Addend" yaml:"Addend"`
+	SymValue uint64 `json:"SymValue" yaml:"SymValue"`
+	SymName  string `json:"SymName" yaml:"SymName"`
+}
+
+// RelocationTable holds the decoded entries of one relocation section
+type RelocationTable struct {
+	Section string            `json:"Section" yaml:"Section"`
+	Offset  uint64            `json:"Offset" yaml:"Offset"`
+	Rela    bool              `json:"Rela" yaml:"Rela"`
+	Entries []RelocationEntry `json:"Entries" yaml:"Entries"`
+}
+
+// readRelocation reads one SHT_RELA or SHT_REL entry from rd, widening
+// 32-bit entries to the 64-bit layout. The symbol index and relocation
+// type are split out of r_info according to the file's class.
+func readRelocation(rd io.Reader, ehdr *Elf64Ehdr, order binary.ByteOrder, rela bool) (Elf64Rela, uint32, uint32, error) {
+	var r Elf64Rela
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		if rela {
+			var rela32 Elf32Rela
+			if err := readStruct(rd, order, &rela32); err != nil {
+				return r, 0, 0, err
+			}
+			r = Elf64Rela{Offset: uint64(rela32.Offset), Info: uint64(rela32.Info), Addend: int64(rela32.Addend)}
+		} else {
+			var rel32 Elf32Rel
+			if err := readStruct(rd, order, &rel32); err != nil {
+				return r, 0, 0, err
+			}
+			r = Elf64Rela{Offset: uint64(rel32.Offset), Info: uint64(rel32.Info)}
+		}
+		return r, uint32(r.Info >> 8), uint32(r.Info & 0xff), nil
+	}
+
+	if rela {
+		if err := readStruct(rd, order, &r); err != nil {
+			return r, 0, 0, err
+		}
+	} else {
+		var rel Elf64Rel
+		if err := readStruct(rd, order, &rel); err != nil {
+			return r, 0, 0, err
+		}
+		r = Elf64Rela{Offset: rel.Offset, Info: rel.Info}
+	}
+	return r, uint32(r.Info >> 32), uint32(r.Info & 0xffffffff), nil
+}
+
+// relocationEntrySize returns the size of one SHT_RELA or SHT_REL entry
+// for the file's class
+func relocationEntrySize(ehdr *Elf64Ehdr, rela bool) uint64 {
+	size := uint64(binary.Size(Elf64Rel{}))
+	if rela {
+		size = uint64(binary.Size(Elf64Rela{}))
+	}
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		size /= 2
+	}
+	return size
+}
+
+// ReadRelocations reads every SHT_RELA and SHT_REL section, resolving each
+// entry's symbol through the symbol table named by the section's sh_link
+func ReadRelocations(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]RelocationTable, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []RelocationTable
+	for i := range shdrwns {
+		rela := shdrwns[i].Type == SHT_RELA
+		if !rela && shdrwns[i].Type != SHT_REL {
+			continue
+		}
+
+		entsize := shdrwns[i].Entsize
+		if entsize == 0 {
+			entsize = relocationEntrySize(ehdr, rela)
+		}
+
+		var syms []Elf64SymWithName
+		if link := int(shdrwns[i].Link); link != 0 && link < len(shdrwns) {
+			syms, err = MakeSymbolsWithName(file, ehdr, order, shdrwns, link)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		table := RelocationTable{Section: shdrwns[i].Name, Offset: shdrwns[i].Offset, Rela: rela}
+		for j := uint64(0); j < shdrwns[i].Size/entsize; j++ {
+			rd := readerAt(file, shdrwns[i].Offset+j*entsize)
+			r, symIndex, relType, err := readRelocation(rd, ehdr, order, rela)
+			if err != nil {
+				return nil, fmt.Errorf("reading relocation %d of %s: %w", j, shdrwns[i].Name, err)
+			}
+
+			entry := RelocationEntry{Offset: r.Offset, Info: r.Info, Type: relType, Addend: r.Addend}
+			if symIndex != 0 && int(symIndex) < len(syms) {
+				sym := syms[symIndex]
+				entry.SymValue = sym.Value
+				entry.SymName = sym.Name
+				// Section symbols are unnamed; show the section they refer to
+				if sym.Name == "" && SymType(sym.Info) == STT_SECTION && int(sym.Shndx) < len(shdrwns) {
+					entry.SymName = shdrwns[sym.Shndx].Name
+				}
+			}
+			table.Entries = append(table.Entries, entry)
+		}
+		tables = append(tables, table)
+	}
+	return tables, nil
+}
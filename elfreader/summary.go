@@ -0,0 +1,170 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"io"
+	"os"
+)
+
+// Summary is a quick overview of a file for triage
+type Summary struct {
+	Type           string `json:"Type" yaml:"Type"`
+	Machine        string `json:"Machine" yaml:"Machine"`
+	FileSize       int64  `json:"FileSize" yaml:"FileSize"`
+	ProgramHeaders int    `json:"ProgramHeaders" yaml:"ProgramHeaders"`
+	SectionHeaders int    `json:"SectionHeaders" yaml:"SectionHeaders"`
+	LoadMemSize    uint64 `json:"LoadMemSize" yaml:"LoadMemSize"`
+	LargestSection string `json:"LargestSection" yaml:"LargestSection"`
+	LargestSize    uint64 `json:"LargestSize" yaml:"LargestSize"`
+	Dynamic        bool   `json:"Dynamic" yaml:"Dynamic"`
+	PIE            bool   `json:"PIE" yaml:"PIE"`
+	Stripped       bool   `json:"Stripped" yaml:"Stripped"`
+}
+
+// FileSize returns the size of a file opened from disk or held in memory,
+// or -1 when the reader cannot tell
+func FileSize(file io.ReaderAt) int64 {
+	switch f := file.(type) {
+	case interface{ Stat() (os.FileInfo, error) }:
+		if info, err := f.Stat(); err == nil {
+			return info.Size()
+		}
+	case interface{ Size() int64 }:
+		return f.Size()
+	}
+	return -1
+}
+
+// ReadSummary gathers the counts and sizes of the file's headers along with
+// the description from ReadIdentity. A file is dynamic when it has a
+// dynamic array.
+func ReadSummary(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*Summary, error) {
+	id, err := ReadIdentity(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	s := &Summary{
+		Type:     id.Type,
+		Machine:  id.Machine,
+		FileSize: FileSize(file),
+		PIE:      id.PIE,
+		Stripped: id.Stripped,
+	}
+
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	s.ProgramHeaders = len(phdrs)
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_LOAD {
+			s.LoadMemSize += phdr.Memsz
+		}
+	}
+
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	s.SectionHeaders = len(shdrwns)
+	for _, shdrwn := range shdrwns {
+		if shdrwn.Size > s.LargestSize {
+			s.LargestSection = shdrwn.Name
+			s.LargestSize = shdrwn.Size
+		}
+	}
+
+	dynamic, err := ReadDynamic(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	s.Dynamic = dynamic != nil
+	return s, nil
+}
+
+// Addresses holds the addresses scripts most often look up. HasBase and
+// HasPhdr are false when the file has no PT_LOAD segment or when its
+// program headers are not loaded.
+type Addresses struct {
+	Entry   uint64 `json:"Entry" yaml:"Entry"`
+	Base    uint64 `json:"Base" yaml:"Base"`
+	HasBase bool   `json:"HasBase" yaml:"HasBase"`
+	Phdr    uint64 `json:"Phdr" yaml:"Phdr"`
+	HasPhdr bool   `json:"HasPhdr" yaml:"HasPhdr"`
+}
+
+// ReadAddresses returns the entry point, the load base (the lowest PT_LOAD
+// address) and the address of the program header table, taken from PT_PHDR
+// or else from the PT_LOAD segment that maps e_phoff
+func ReadAddresses(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*Addresses, error) {
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	addrs := &Addresses{Entry: ehdr.Entry}
+	for _, phdr := range phdrs {
+		switch phdr.Type {
+		case PT_LOAD:
+			if !addrs.HasBase || phdr.Vaddr < addrs.Base {
+				addrs.Base = phdr.Vaddr
+				addrs.HasBase = true
+			}
+		case PT_PHDR:
+			addrs.Phdr = phdr.Vaddr
+			addrs.HasPhdr = true
+		}
+	}
+	if addrs.HasPhdr || len(phdrs) == 0 {
+		return addrs, nil
+	}
+	for _, phdr := range phdrs {
+		if phdr.Type == PT_LOAD && ehdr.Phoff >= phdr.Offset && ehdr.Phoff-phdr.Offset < phdr.Filesz {
+			addrs.Phdr = ehdr.Phoff - phdr.Offset + phdr.Vaddr
+			addrs.HasPhdr = true
+			break
+		}
+	}
+	return addrs, nil
+}
+
+// Counts holds the number of entries in the file's main tables. Symbols
+// and Relocations add up every symbol table and every relocation section.
+type Counts struct {
+	ProgramHeaders int    `json:"ProgramHeaders" yaml:"ProgramHeaders"`
+	SectionHeaders int    `json:"SectionHeaders" yaml:"SectionHeaders"`
+	Symbols        uint64 `json:"Symbols" yaml:"Symbols"`
+	Relocations    uint64 `json:"Relocations" yaml:"Relocations"`
+}
+
+// ReadCounts counts the entries of the program and section headers, the
+// symbol tables and the relocation sections. Symbols and relocations are
+// counted from the sizes of their sections without being read.
+func ReadCounts(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*Counts, error) {
+	phdrs, err := ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Counts{ProgramHeaders: len(phdrs), SectionHeaders: len(shdrwns)}
+	for _, shdrwn := range shdrwns {
+		entsize := shdrwn.Entsize
+		switch shdrwn.Type {
+		case SHT_SYMTAB, SHT_DYNSYM:
+			if entsize == 0 {
+				entsize = symbolEntrySize(ehdr)
+			}
+			c.Symbols += shdrwn.Size / entsize
+		case SHT_RELA, SHT_REL:
+			if entsize == 0 {
+				entsize = relocationEntrySize(ehdr, shdrwn.Type == SHT_RELA)
+			}
+			c.Relocations += shdrwn.Size / entsize
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,226 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// Symbol bindings, the high nibble of st_info
+const (
+	STB_LOCAL      = 0
+	STB_GLOBAL     = 1
+	STB_WEAK       = 2
+	STB_GNU_UNIQUE = 10
+)
+
+// Symbol types, the low nibble of st_info
+const (
+	STT_NOTYPE    = 0
+	STT_OBJECT    = 1
+	STT_FUNC      = 2
+	STT_SECTION   = 3
+	STT_FILE      = 4
+	STT_COMMON    = 5
+	STT_TLS       = 6
+	STT_GNU_IFUNC = 10
+)
+
+// Symbol visibilities, the low two bits of st_other
+const (
+	STV_DEFAULT   = 0
+	STV_INTERNAL  = 1
+	STV_HIDDEN    = 2
+	STV_PROTECTED = 3
+)
+
+// SymBind returns the binding held in st_info
+func SymBind(info uint8) uint8 {
+	return info >> 4
+}
+
+// SymType returns the type held in st_info
+func SymType(info uint8) uint8 {
+	return info & 0xf
+}
+
+// SymVisibility returns the visibility held in st_other
+func SymVisibility(other uint8) uint8 {
+	return other & 0x3
+}
+
+type Elf64Sym struct {
+	Name  uint32
+	Info  uint8
+	Other uint8
+	Shndx uint16
+	Value uint64
+	Size  uint64
+}
+
+type Elf32Sym struct {
+	Name  uint32
+	Value uint32
+	Size  uint32
+	Info  uint8
+	Other uint8
+	Shndx uint16
+}
+
+type Elf64SymWithName struct {
+	Name  string `json:"Name" yaml:"Name"`
+	Info  uint8  `json:"Info" yaml:"Info"`
+	Other uint8  `json:"Other" yaml:"Other"`
+	Shndx uint16 `json:"Shndx" yaml:"Shndx"`
+	Value uint64 `json:"Value" yaml:"Value"`
+	Size  uint64 `json:"Size" yaml:"Size"`
+}
+
+// SymbolTable holds the resolved entries of one symbol table section
+type SymbolTable struct {
+	Section string             `json:"Section" yaml:"Section"`
+	Symbols []Elf64SymWithName `json:"Symbols" yaml:"Symbols"`
+}
+
+// SymbolMatch is a symbol found by FindSymbols, along with the table it
+// came from and its index there
+type SymbolMatch struct {
+	Table  string           `json:"Table" yaml:"Table"`
+	Index  int              `json:"Index" yaml:"Index"`
+	Symbol Elf64SymWithName `json:"Symbol" yaml:"Symbol"`
+}
+
+// readSymbol reads one symbol table entry from rd,
+// widening 32-bit entries to the 64-bit layout
+func readSymbol(rd io.Reader, ehdr *Elf64Ehdr, order binary.ByteOrder) (Elf64Sym, error) {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		var sym32 Elf32Sym
+		if err := readStruct(rd, order, &sym32); err != nil {
+			return Elf64Sym{}, err
+		}
+		return Elf64Sym{
+			Name:  sym32.Name,
+			Info:  sym32.Info,
+			Other: sym32.Other,
+			Shndx: sym32.Shndx,
+			Value: uint64(sym32.Value),
+			Size:  uint64(sym32.Size),
+		}, nil
+	}
+
+	var sym Elf64Sym
+	err := readStruct(rd, order, &sym)
+	return sym, err
+}
+
+// symbolEntrySize returns the size of one symbol table entry for the file's class
+func symbolEntrySize(ehdr *Elf64Ehdr) uint64 {
+	if ehdr.Ident[EI_CLASS] == ELFCLASS32 {
+		return uint64(binary.Size(Elf32Sym{}))
+	}
+	return uint64(binary.Size(Elf64Sym{}))
+}
+
+// WalkSymbols reads the entries of the SHT_SYMTAB or SHT_DYNSYM section at
+// index one at a time, resolving their names through the string table named
+// by its sh_link, and calls fn with each. It stops at the first error fn
+// returns, so that large tables can be processed without being held in
+// memory.
+func WalkSymbols(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, shdrwns []Elf64ShdrWithName, index int, fn func(i int, symwn Elf64SymWithName) error) error {
+	symtab := shdrwns[index]
+	entsize := symtab.Entsize
+	if entsize == 0 {
+		entsize = symbolEntrySize(ehdr)
+	}
+
+	// The names are in the string table named by sh_link, .strtab for
+	// .symtab and .dynstr for .dynsym, never in the section name table
+	var strtab []byte
+	if int(symtab.Link) < len(shdrwns) {
+		link := shdrwns[symtab.Link]
+		if link.Type != SHT_STRTAB {
+			warnf(file, "symbol table %s links to section [%d] %s, which is not a string table", symtab.Name, symtab.Link, link.Name)
+		}
+		var err error
+		strtab, err = ReadStringTable(file, link.Offset, link.Size)
+		if err != nil {
+			return err
+		}
+	} else {
+		warnf(file, "symbol table %s links to section %d, which does not exist", symtab.Name, symtab.Link)
+	}
+
+	count := symtab.Size / entsize
+	for i := uint64(0); i < count; i++ {
+		sym, err := readSymbol(readerAt(file, symtab.Offset+i*entsize), ehdr, order)
+		if err != nil {
+			return fmt.Errorf("reading symbol %d of %s: %w", i, symtab.Name, err)
+		}
+		symwn := Elf64SymWithName{
+			Name:  GetString(strtab, sym.Name),
+			Info:  sym.Info,
+			Other: sym.Other,
+			Shndx: sym.Shndx,
+			Value: sym.Value,
+			Size:  sym.Size,
+		}
+		if err := fn(int(i), symwn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MakeSymbolsWithName reads every entry of a SHT_SYMTAB or SHT_DYNSYM section
+// and resolves the names through the string table named by its sh_link
+func MakeSymbolsWithName(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, shdrwns []Elf64ShdrWithName, index int) ([]Elf64SymWithName, error) {
+	symwns := []Elf64SymWithName{}
+	err := WalkSymbols(file, ehdr, order, shdrwns, index, func(i int, symwn Elf64SymWithName) error {
+		symwns = append(symwns, symwn)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return symwns, nil
+}
+
+// ReadSymbolTables reads every symbol table in the file
+func ReadSymbolTables(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) ([]SymbolTable, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []SymbolTable
+	for i := range shdrwns {
+		if shdrwns[i].Type != SHT_SYMTAB && shdrwns[i].Type != SHT_DYNSYM {
+			continue
+		}
+		symwns, err := MakeSymbolsWithName(file, ehdr, order, shdrwns, i)
+		if err != nil {
+			return nil, err
+		}
+		tables = append(tables, SymbolTable{Section: shdrwns[i].Name, Symbols: symwns})
+	}
+	return tables, nil
+}
+
+// FindSymbols returns the symbols of every symbol table whose name matches re
+func FindSymbols(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder, re *regexp.Regexp) ([]SymbolMatch, error) {
+	tables, err := ReadSymbolTables(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []SymbolMatch{}
+	for _, table := range tables {
+		for i, sym := range table.Symbols {
+			if sym.Name != "" && re.MatchString(sym.Name) {
+				matches = append(matches, SymbolMatch{Table: table.Section, Index: i, Symbol: sym})
+			}
+		}
+	}
+	return matches, nil
+}
@@ -0,0 +1,100 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+)
+
+// symbolFile returns a file with both a dynamic and a static symbol table,
+// whose string tables hold different names at the same offsets. The
+// .dynsym links to dynsymLink.
+func symbolFile(dynsymLink uint32) []byte {
+	order := binary.LittleEndian
+	function := uint8(STB_GLOBAL<<4 | STT_FUNC)
+	return buildELF(order, nil, []testSection{
+		{name: ".dynstr", typ: SHT_STRTAB, flags: SHF_ALLOC, data: []byte("\x00puts\x00exit\x00")},
+		{name: ".dynsym", typ: SHT_DYNSYM, flags: SHF_ALLOC, link: dynsymLink, info: 1, entsize: 24, data: symbolData(order, []Elf64Sym{
+			{},
+			{Name: 1, Info: function},
+			{Name: 6, Info: function},
+		})},
+		{name: ".strtab", typ: SHT_STRTAB, data: []byte("\x00main\x00_start\x00helper\x00")},
+		{name: ".symtab", typ: SHT_SYMTAB, link: 3, info: 1, entsize: 24, data: symbolData(order, []Elf64Sym{
+			{},
+			{Name: 1, Info: function, Shndx: 1, Value: 0x401000},
+			{Name: 6, Info: function, Shndx: 1, Value: 0x401020},
+			{Name: 13, Info: function, Shndx: 1, Value: 0x401040},
+		})},
+	})
+}
+
+// symbolNamesOf returns the names of the symbols in each table, by section
+func symbolNamesOf(tables []SymbolTable) map[string]string {
+	names := make(map[string]string)
+	for _, table := range tables {
+		var list []string
+		for _, sym := range table.Symbols {
+			list = append(list, sym.Name)
+		}
+		names[table.Section] = strings.Join(list, ",")
+	}
+	return names
+}
+
+func TestReadSymbolTablesFollowsLink(t *testing.T) {
+	f, err := Parse(symbolFile(1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var warnings []string
+	f.Warn = func(msg string) { warnings = append(warnings, msg) }
+
+	tables, err := ReadSymbolTables(f, f.Ehdr, f.Order)
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := symbolNamesOf(tables)
+	if names[".dynsym"] != ",puts,exit" {
+		t.Errorf(".dynsym names = %q, want them from .dynstr", names[".dynsym"])
+	}
+	if names[".symtab"] != ",main,_start,helper" {
+		t.Errorf(".symtab names = %q, want them from .strtab", names[".symtab"])
+	}
+	if len(warnings) != 0 {
+		t.Errorf("warnings = %q", warnings)
+	}
+}
+
+func TestReadSymbolTablesBadLink(t *testing.T) {
+	tests := []struct {
+		name    string
+		link    uint32
+		symbols string
+		warning string
+	}{
+		{"not a string table", 4, ",,", "symbol table .dynsym links to section [4] .symtab, which is not a string table"},
+		{"static string table", 3, ",main,_start", ""},
+		{"no such section", 99, ",<corrupt>,<corrupt>", "symbol table .dynsym links to section 99, which does not exist"},
+	}
+	for _, tt := range tests {
+		f, err := NewFile(bytes.NewReader(symbolFile(tt.link)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var warnings []string
+		f.Warn = func(msg string) { warnings = append(warnings, msg) }
+
+		tables, err := ReadSymbolTables(f, f.Ehdr, f.Order)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := symbolNamesOf(tables)[".dynsym"]; got != tt.symbols {
+			t.Errorf("%s: .dynsym names = %q, want %q", tt.name, got, tt.symbols)
+		}
+		if got := strings.Join(warnings, "\n"); got != tt.warning {
+			t.Errorf("%s: warnings = %q, want %q", tt.name, got, tt.warning)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package elfreader
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// Severities of the problems Validate finds. Errors break the ELF
+// specification; warnings are unusual but leave the file readable.
+const (
+	SEVERITY_ERROR   = "error"
+	SEVERITY_WARNING = "warning"
+)
+
+// Problem is one departure from the ELF specification
+type Problem struct {
+	Severity string `json:"Severity" yaml:"Severity"`
+	Message  string `json:"Message" yaml:"Message"`
+}
+
+// Validate checks the file against invariants of the ELF specification: the
+// identification bytes and versions, the header entry sizes, the section
+// header string table index, a null section header 0, sections that do not
+// overlap in the file and a dynamic array ending in DT_NULL. A table that
+// cannot be read is reported as an error and the checks that need it are
+// skipped, so that one damaged table does not hide the other problems.
+func Validate(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) []Problem {
+	var problems []Problem
+	add := func(severity, format string, args ...interface{}) {
+		problems = append(problems, Problem{severity, fmt.Sprintf(format, args...)})
+	}
+
+	if c := ehdr.Ident[EI_CLASS]; c != ELFCLASS32 && c != ELFCLASS64 {
+		add(SEVERITY_ERROR, "invalid class %d in EI_CLASS", c)
+	}
+	if d := ehdr.Ident[EI_DATA]; d != ELFDATA2LSB && d != ELFDATA2MSB {
+		add(SEVERITY_ERROR, "invalid data encoding %d in EI_DATA", d)
+	}
+	if v := ehdr.Ident[EI_VERSION]; v != EV_CURRENT {
+		add(SEVERITY_WARNING, "EI_VERSION is %d, not %d", v, EV_CURRENT)
+	}
+	if ehdr.Version != EV_CURRENT {
+		add(SEVERITY_WARNING, "e_version is %d, not %d", ehdr.Version, EV_CURRENT)
+	}
+
+	sizes := ExpectedHeaderSizes(ehdr)
+	if ehdr.Ehsize != sizes.Ehsize {
+		add(SEVERITY_WARNING, "e_ehsize is %d, expected %d", ehdr.Ehsize, sizes.Ehsize)
+	}
+	if ehdr.Phnum != 0 && ehdr.Phentsize != sizes.Phentsize {
+		add(SEVERITY_ERROR, "e_phentsize is %d, expected %d", ehdr.Phentsize, sizes.Phentsize)
+	}
+	if ehdr.Shoff != 0 && ehdr.Shentsize != sizes.Shentsize {
+		add(SEVERITY_ERROR, "e_shentsize is %d, expected %d", ehdr.Shentsize, sizes.Shentsize)
+	}
+
+	if _, err := ReadProgramHeaders(file, ehdr, order); err != nil {
+		add(SEVERITY_ERROR, "program headers: %v", err)
+	}
+
+	shnum, shstrndx, err := sectionCount(file, ehdr, order)
+	if err != nil {
+		add(SEVERITY_ERROR, "section headers: %v", err)
+		return problems
+	}
+	if shnum == 0 {
+		return problems
+	}
+	if uint64(shstrndx) >= shnum {
+		add(SEVERITY_ERROR, "e_shstrndx %d is out of range (only %d sections)", shstrndx, shnum)
+		return problems
+	}
+	if shstrndx == SHN_UNDEF {
+		add(SEVERITY_WARNING, "e_shstrndx is 0, so the sections have no names")
+	}
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		add(SEVERITY_ERROR, "section headers: %v", err)
+		return problems
+	}
+	if shdrwns[0].Type != SHT_NULL {
+		add(SEVERITY_ERROR, "section header 0 has type %s, not NULL", SectionTypeName(shdrwns[0].Type))
+	}
+	for _, overlap := range sectionOverlaps(shdrwns) {
+		add(SEVERITY_ERROR, "%s", overlap)
+	}
+
+	dynamic, err := ReadDynamic(file, ehdr, order)
+	if err != nil {
+		add(SEVERITY_ERROR, "dynamic array: %v", err)
+	} else if dynamic != nil && (len(dynamic.Entries) == 0 || dynamic.Entries[len(dynamic.Entries)-1].Tag != DT_NULL) {
+		add(SEVERITY_ERROR, "dynamic array at offset 0x%x is not terminated by DT_NULL", dynamic.Offset)
+	}
+	return problems
+}
@@ -0,0 +1,269 @@
+package elfreader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// GNU symbol versioning section types
+const (
+	SHT_GNU_VERDEF  = 0x6ffffffd
+	SHT_GNU_VERNEED = 0x6ffffffe
+	SHT_GNU_VERSYM  = 0x6fffffff
+)
+
+// Version definition flags and special .gnu.version indexes
+const (
+	VER_FLG_BASE     = 0x1
+	VER_FLG_WEAK     = 0x2
+	VER_NDX_LOCAL    = 0
+	VER_NDX_GLOBAL   = 1
+	VERSYM_HIDDEN    = 0x8000
+	VERSYM_INDEX_MSK = 0x7fff
+)
+
+// Verdef and Verneed records have the same layout in both classes
+type ElfVerdef struct {
+	Version uint16
+	Flags   uint16
+	Ndx     uint16
+	Cnt     uint16
+	Hash    uint32
+	Aux     uint32
+	Next    uint32
+}
+
+type ElfVerdaux struct {
+	Name uint32
+	Next uint32
+}
+
+type ElfVerneed struct {
+	Version uint16
+	Cnt     uint16
+	File    uint32
+	Aux     uint32
+	Next    uint32
+}
+
+type ElfVernaux struct {
+	Hash  uint32
+	Flags uint16
+	Other uint16
+	Name  uint32
+	Next  uint32
+}
+
+// VersionDefinition is one entry of .gnu.version_d. The first name is the
+// version being defined, any others are the versions it inherits from.
+type VersionDefinition struct {
+	Index uint16   `json:"Index" yaml:"Index"`
+	Flags uint16   `json:"Flags" yaml:"Flags"`
+	Names []string `json:"Names" yaml:"Names"`
+}
+
+// VersionNeed is one version required from a needed library
+type VersionNeed struct {
+	Name  string `json:"Name" yaml:"Name"`
+	Flags uint16 `json:"Flags" yaml:"Flags"`
+	Index uint16 `json:"Index" yaml:"Index"`
+}
+
+// VersionRequirement lists the versions required from one needed library
+type VersionRequirement struct {
+	File     string        `json:"File" yaml:"File"`
+	Versions []VersionNeed `json:"Versions" yaml:"Versions"`
+}
+
+// SymbolVersion is the version a dynamic symbol is bound to
+type SymbolVersion struct {
+	Symbol  string `json:"Symbol" yaml:"Symbol"`
+	Index   uint16 `json:"Index" yaml:"Index"`
+	Version string `json:"Version" yaml:"Version"`
+	Hidden  bool   `json:"Hidden" yaml:"Hidden"`
+}
+
+// VersionInfo holds the decoded GNU symbol versioning sections
+type VersionInfo struct {
+	Symbols      []SymbolVersion      `json:"Symbols" yaml:"Symbols"`
+	Definitions  []VersionDefinition  `json:"Definitions" yaml:"Definitions"`
+	Requirements []VersionRequirement `json:"Requirements" yaml:"Requirements"`
+}
+
+// parseVerdef walks the count version definitions of a .gnu.version_d
+// section. Each record and its auxiliary names are linked by byte offsets
+// relative to the record.
+func parseVerdef(data []byte, order binary.ByteOrder, count uint32, strtab []byte) ([]VersionDefinition, error) {
+	r := bytes.NewReader(data)
+	var defs []VersionDefinition
+	var pos uint64
+	for i := uint32(0); i < count; i++ {
+		var vd ElfVerdef
+		if err := readStruct(readerAt(r, pos), order, &vd); err != nil {
+			return defs, fmt.Errorf("reading version definition %d: %w", i, err)
+		}
+
+		def := VersionDefinition{Index: vd.Ndx, Flags: vd.Flags}
+		auxPos := pos + uint64(vd.Aux)
+		for j := uint16(0); j < vd.Cnt; j++ {
+			var vda ElfVerdaux
+			if err := readStruct(readerAt(r, auxPos), order, &vda); err != nil {
+				return defs, fmt.Errorf("reading name %d of version definition %d: %w", j, i, err)
+			}
+			def.Names = append(def.Names, GetString(strtab, vda.Name))
+			if vda.Next == 0 {
+				break
+			}
+			auxPos += uint64(vda.Next)
+		}
+		defs = append(defs, def)
+
+		if vd.Next == 0 {
+			break
+		}
+		pos += uint64(vd.Next)
+	}
+	return defs, nil
+}
+
+// parseVerneed walks the count entries of a .gnu.version_r section, one per
+// needed library, each followed by the versions required from it
+func parseVerneed(data []byte, order binary.ByteOrder, count uint32, strtab []byte) ([]VersionRequirement, error) {
+	r := bytes.NewReader(data)
+	var reqs []VersionRequirement
+	var pos uint64
+	for i := uint32(0); i < count; i++ {
+		var vn ElfVerneed
+		if err := readStruct(readerAt(r, pos), order, &vn); err != nil {
+			return reqs, fmt.Errorf("reading version requirement %d: %w", i, err)
+		}
+
+		req := VersionRequirement{File: GetString(strtab, vn.File)}
+		auxPos := pos + uint64(vn.Aux)
+		for j := uint16(0); j < vn.Cnt; j++ {
+			var vna ElfVernaux
+			if err := readStruct(readerAt(r, auxPos), order, &vna); err != nil {
+				return reqs, fmt.Errorf("reading version %d of requirement %d: %w", j, i, err)
+			}
+			req.Versions = append(req.Versions, VersionNeed{
+				Name:  GetString(strtab, vna.Name),
+				Flags: vna.Flags,
+				Index: vna.Other,
+			})
+			if vna.Next == 0 {
+				break
+			}
+			auxPos += uint64(vna.Next)
+		}
+		reqs = append(reqs, req)
+
+		if vn.Next == 0 {
+			break
+		}
+		pos += uint64(vn.Next)
+	}
+	return reqs, nil
+}
+
+// ReadVersionInfo decodes the .gnu.version_d, .gnu.version_r and
+// .gnu.version sections and binds each dynamic symbol to its version name.
+// It returns nil for files without symbol versioning.
+func ReadVersionInfo(file io.ReaderAt, ehdr *Elf64Ehdr, order binary.ByteOrder) (*VersionInfo, error) {
+	shdrwns, err := MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &VersionInfo{}
+	versym := -1
+	found := false
+	for i, shdrwn := range shdrwns {
+		if shdrwn.Type == SHT_GNU_VERSYM {
+			versym = i
+			found = true
+			continue
+		}
+		if shdrwn.Type != SHT_GNU_VERDEF && shdrwn.Type != SHT_GNU_VERNEED {
+			continue
+		}
+		found = true
+
+		data, err := ReadSectionData(file, shdrwn)
+		if err != nil {
+			return nil, err
+		}
+		var strtab []byte
+		if link := int(shdrwn.Link); link != 0 && link < len(shdrwns) {
+			strtab, err = ReadStringTable(file, shdrwns[link].Offset, shdrwns[link].Size)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// sh_info holds the number of entries
+		if shdrwn.Type == SHT_GNU_VERDEF {
+			defs, err := parseVerdef(data, order, shdrwn.Info, strtab)
+			if err != nil {
+				return nil, fmt.Errorf("section '%s': %w", shdrwn.Name, err)
+			}
+			info.Definitions = append(info.Definitions, defs...)
+		} else {
+			reqs, err := parseVerneed(data, order, shdrwn.Info, strtab)
+			if err != nil {
+				return nil, fmt.Errorf("section '%s': %w", shdrwn.Name, err)
+			}
+			info.Requirements = append(info.Requirements, reqs...)
+		}
+	}
+	if !found {
+		return nil, nil
+	}
+	if versym < 0 {
+		return info, nil
+	}
+
+	// Version indexes name either a definition or a required version
+	names := map[uint16]string{
+		VER_NDX_LOCAL:  "*local*",
+		VER_NDX_GLOBAL: "*global*",
+	}
+	for _, def := range info.Definitions {
+		if len(def.Names) > 0 && def.Flags&VER_FLG_BASE == 0 {
+			names[def.Index] = def.Names[0]
+		}
+	}
+	for _, req := range info.Requirements {
+		for _, need := range req.Versions {
+			names[need.Index] = need.Name
+		}
+	}
+
+	var syms []Elf64SymWithName
+	if link := int(shdrwns[versym].Link); link != 0 && link < len(shdrwns) {
+		syms, err = MakeSymbolsWithName(file, ehdr, order, shdrwns, link)
+		if err != nil {
+			return nil, err
+		}
+	}
+	data, err := ReadSectionData(file, shdrwns[versym])
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i+2 <= len(data); i += 2 {
+		value := order.Uint16(data[i:])
+		index := value & VERSYM_INDEX_MSK
+		sv := SymbolVersion{Index: index, Hidden: value&VERSYM_HIDDEN != 0}
+		if i/2 < len(syms) {
+			sv.Symbol = syms[i/2].Name
+		}
+		if name, ok := names[index]; ok {
+			sv.Version = name
+		} else {
+			sv.Version = fmt.Sprintf("<unknown: %d>", index)
+		}
+		info.Symbols = append(info.Symbols, sv)
+	}
+	return info, nil
+}
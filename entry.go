@@ -0,0 +1,31 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintEntryBytes displays the first n bytes of code at the entry point as
+// hex, sixteen to a line, each line led by its address
+func PrintEntryBytes(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, n uint64) error {
+	entry, err := elfreader.ReadEntryBytes(file, ehdr, order, n)
+	if err != nil {
+		return err
+	}
+	if entry == nil {
+		p.Printf("This file has no entry point.\n")
+		return nil
+	}
+
+	p.Printf("Entry point %s at file offset %s:\n", p.colorAddr("0x%x", entry.Entry), p.colorAddr("0x%x", entry.Offset))
+	for start := 0; start < len(entry.Bytes); start += 16 {
+		end := start + 16
+		if end > len(entry.Bytes) {
+			end = len(entry.Bytes)
+		}
+		p.Printf("  %s  %s\n", p.colorAddr("0x%08x", entry.Entry+uint64(start)), hexBytes(entry.Bytes[start:end]))
+	}
+	return nil
+}
@@ -2,4 +2,8 @@ module color-readelf
 
 go 1.13
 
-require golang.org/dl v0.0.0-20241001165935-bedb0f791d00 // indirect
+require (
+	github.com/klauspost/compress v1.12.3
+	golang.org/dl v0.0.0-20241001165935-bedb0f791d00 // indirect
+	gopkg.in/yaml.v3 v3.0.1
+)
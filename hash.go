@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// hashTableName names a hash table by its section, or by the dynamic array
+// when the file has no section headers
+func hashTableName(table elfreader.HashTable) string {
+	if table.Section == "" {
+		return "<dynamic>"
+	}
+	return table.Section
+}
+
+// PrintHashTables displays the structure of every symbol hash table and how
+// many buckets hold chains of each length
+func PrintHashTables(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	tables, err := elfreader.ReadHashTables(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		p.Printf("There are no hash tables in this file.\n")
+		return nil
+	}
+
+	for i, table := range tables {
+		if i > 0 {
+			p.Printf("\n")
+		}
+		if table.GNU {
+			p.Printf("GNU hash table '%s' at offset %s:\n", p.colorSection(hashTableName(table)), p.colorAddr("0x%x", table.Offset))
+			p.Printf("  Buckets:            %d\n", table.Nbucket)
+			p.Printf("  Symbol offset:      %d\n", table.Symoffset)
+			p.Printf("  Bloom filter words: %d\n", table.BloomSize)
+			p.Printf("  Bloom shift:        %d\n", table.BloomShift)
+		} else {
+			p.Printf("SysV hash table '%s' at offset %s:\n", p.colorSection(hashTableName(table)), p.colorAddr("0x%x", table.Offset))
+			p.Printf("  Buckets:            %d\n", table.Nbucket)
+			p.Printf("  Chains:             %d\n", table.Nchain)
+		}
+
+		p.Printf("  Chain length  Buckets\n")
+		for length, count := range table.Histogram() {
+			p.Printf("  %12d  %7d\n", length, count)
+		}
+	}
+	return nil
+}
+
+// PrintHistogram displays, for every symbol hash table, how many buckets
+// hold chains of each length and the share of symbols found within that
+// many probes, like readelf -I
+func PrintHistogram(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	tables, err := elfreader.ReadHashTables(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		p.Printf("There are no hash tables in this file.\n")
+		return nil
+	}
+
+	for i, table := range tables {
+		if i > 0 {
+			p.Printf("\n")
+		}
+		p.Printf("Histogram for '%s' bucket list length (total of %d buckets):\n", p.colorSection(hashTableName(table)), table.Nbucket)
+		p.Printf(" Length  Number     %% of total  Coverage\n")
+
+		var symbols uint64
+		for _, length := range table.ChainLengths {
+			symbols += uint64(length)
+		}
+
+		var covered uint64
+		for length, count := range table.Histogram() {
+			share := percent(uint64(count), uint64(table.Nbucket))
+			if length == 0 {
+				p.Printf("%7d  %-10d (%5.1f%%)\n", length, count, share)
+				continue
+			}
+			covered += uint64(length) * uint64(count)
+			p.Printf("%7d  %-10d (%5.1f%%)    %5.1f%%\n", length, count, share, percent(covered, symbols))
+		}
+	}
+	return nil
+}
+
+// percent returns n as a percentage of total, or 0 for an empty total
+func percent(n, total uint64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
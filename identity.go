@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// yesNo renders a boolean for the summary output
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
+
+// PrintIdentity displays a concise identity summary of the file
+func PrintIdentity(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	id, err := elfreader.ReadIdentity(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+
+	buildID := id.BuildID
+	if buildID == "" {
+		buildID = "(none)"
+	}
+	soname := id.Soname
+	if soname == "" {
+		soname = "(none)"
+	}
+
+	p.Printf("Build ID:  %s\n", buildID)
+	p.Printf("SONAME:    %s\n", soname)
+	p.Printf("Class:     %s\n", id.Class)
+	p.Printf("Machine:   %s\n", id.Machine)
+	p.Printf("Type:      %s\n", id.Type)
+	p.Printf("PIE:       %s\n", yesNo(id.PIE))
+	p.Printf("Stripped:  %s\n", yesNo(id.Stripped))
+	return nil
+}
+
+// PrintDebugLink displays the separate debug file named by .gnu_debuglink
+func PrintDebugLink(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	link, err := elfreader.ReadDebugLink(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if link == nil {
+		p.Printf("There is no .gnu_debuglink section in this file.\n")
+		return nil
+	}
+
+	p.Printf("Debug file:  %s\n", link.File)
+	p.Printf("CRC32:       %s\n", p.colorAddr("0x%08x", link.CRC))
+	return nil
+}
@@ -1,293 +1,1103 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
 	"encoding/binary"
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
 	"os"
 	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"color-readelf/elfreader"
 )
 
 // Constants for color codes
 const (
 	BLUE_TEXT    = "\033[0;34m"
 	GREEN_TEXT   = "\033[0;32m"
+	RED_TEXT     = "\033[0;31m"
 	MAGENTA_TEXT = "\033[0;35m"
+	DIM_TEXT     = "\033[2m"
 	RESET_TEXT   = "\033[0m"
 )
 
-type Elf64Ehdr struct {
-	Ident     [16]byte
-	Type      uint16
-	Machine   uint16
-	Version   uint32
-	Entry     uint64
-	Phoff     uint64
-	Shoff     uint64
-	Flags     uint32
-	Ehsize    uint16
-	Phentsize uint16
-	Phnum     uint16
-	Shentsize uint16
-	Shnum     uint16
-	Shstrndx  uint16
-}
-
-type Elf64Phdr struct {
-	Type   uint32
-	Flags  uint32
-	Offset uint64
-	Vaddr  uint64
-	Paddr  uint64
-	Filesz uint64
-	Memsz  uint64
-	Align  uint64
-}
-
-type Elf64Shdr struct {
-	Name      uint32
-	Type      uint32
-	Flags     uint64
-	Addr      uint64
-	Offset    uint64
-	Size      uint64
-	Link      uint32
-	Info      uint32
-	Addralign uint64
-	Entsize   uint64
-}
-
-type Elf64ShdrWithName struct {
-	Name      string
-	Type      uint32
-	Flags     uint64
-	Addr      uint64
-	Offset    uint64
-	Size      uint64
-	Link      uint32
-	Info      uint32
-	Addralign uint64
-	Entsize   uint64
-}
-
-// ColorPrint prints the formatted string with color if a substring from the map is found
-func ColorPrint(format string, args ...interface{}) {
-	buffer := fmt.Sprintf(format, args...)
-
-	// Define color mappings with associated regex patterns
-	colorMappings := []struct {
-		pattern *regexp.Regexp
-		color   string
-	}{
-		{regexp.MustCompile(`(?i)section`), BLUE_TEXT},
-		{regexp.MustCompile(`(?i)program`), GREEN_TEXT},
-		{regexp.MustCompile(`(0x[0-9a-f]+)`), MAGENTA_TEXT},
-	}
-
-	// Apply each color mapping
-	for _, mapping := range colorMappings {
-		buffer = mapping.pattern.ReplaceAllStringFunc(buffer, func(s string) string {
-			return mapping.color + s + RESET_TEXT
-		})
-	}
-
-	fmt.Printf("%s", buffer)
-}
-
-// PrintELFHeader displays the ELF header information
-func PrintELFHeader(ehdr *Elf64Ehdr) {
-	ColorPrint("This image displays information about a machine and operating system:\n")
-	ColorPrint("  Magic:   ")
-	for _, b := range ehdr.Ident {
-		ColorPrint("%02x ", b)
-	}
-	ColorPrint("\n")
-	ColorPrint("  Class:                             %d\n", ehdr.Ident[4])
-	ColorPrint("  Data:                              %d\n", ehdr.Ident[5])
-	ColorPrint("  Version:                           %d\n", ehdr.Ident[6])
-	ColorPrint("  OS/ABI:                            %d\n", ehdr.Ident[7])
-	ColorPrint("  ABI Version:                       %d\n", ehdr.Ident[8])
-	ColorPrint("  Type:                              %d\n", ehdr.Type)
-	ColorPrint("  Machine:                           %d\n", ehdr.Machine)
-	ColorPrint("  Version:                           0x%x\n", ehdr.Version)
-	ColorPrint("  Entry point address:               0x%x\n", ehdr.Entry)
-	ColorPrint("  Start of program headers:          %d (bytes into file)\n", ehdr.Phoff)
-	ColorPrint("  Start of section headers:          %d (bytes into file)\n", ehdr.Shoff)
-	ColorPrint("  Flags:                             0x%x\n", ehdr.Flags)
-	ColorPrint("  Size of this header:               %d (bytes)\n", ehdr.Ehsize)
-	ColorPrint("  Size of program headers:           %d (bytes)\n", ehdr.Phentsize)
-	ColorPrint("  Number of program headers:         %d\n", ehdr.Phnum)
-	ColorPrint("  Size of section headers:           %d (bytes)\n", ehdr.Shentsize)
-	ColorPrint("  Number of section headers:         %d\n", ehdr.Shnum)
-	ColorPrint("  Section header string table index: %d\n", ehdr.Shstrndx)
-}
-
-func JSONOutputELFHeader(ehdr *Elf64Ehdr) {
-	jsonData, err := json.MarshalIndent(ehdr, "", "  ")
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error converting to JSON: %v\n", err)
-		os.Exit(1)
+// Printer writes the text output to Out. Color is decided once at startup
+// from the --color flag, falling back to detectColor. Wide selects the
+// one-line-per-entry table layouts and stops long names being shortened.
+// VerboseSections lists every section field on a line of its own. Palette
+// maps each color category to its escape sequence; categories missing from
+// it are left uncolored. SectionFilter, when set, limits the section
+// headers shown to those whose name it matches, and SegmentType limits the
+// program headers to segments of that type. SectionSort is the order the
+// section headers are listed in. Raw shows headers as plain numbers,
+// without names or flag letters. Demangle shows C++ symbol names in their
+// source form. Limit, when not zero, caps the entries of each symbol,
+// relocation or section list that are printed. HeaderFields, when set,
+// limits the ELF header lines shown to the fields it holds. Warn reports
+// the problems elfreader notices while reading a file.
+type Printer struct {
+	Out             io.Writer
+	Color           bool
+	Palette         map[string]string
+	Wide            bool
+	VerboseSections bool
+	SectionFilter   *regexp.Regexp
+	SegmentType     *uint32
+	SectionSort     string
+	Raw             bool
+	Demangle        bool
+	Limit           int
+	HeaderFields    map[string]bool
+	Warn            func(msg string)
+}
+
+// Printf prints the formatted string as is. Fields are colored by the
+// caller through the color helpers below, so that only what they hold
+// decides their color.
+func (p *Printer) Printf(format string, args ...interface{}) {
+	fmt.Fprintf(p.Out, format, args...)
+}
+
+// warn reports a problem through Warn, when it is set
+func (p *Printer) warn(format string, args ...interface{}) {
+	if p.Warn != nil {
+		p.Warn(fmt.Sprintf(format, args...))
+	}
+}
+
+// paint wraps s in the color of the category when color is enabled
+func (p *Printer) paint(category, s string) string {
+	color := p.Palette[category]
+	if !p.Color || color == "" || s == "" {
+		return s
+	}
+	return color + s + RESET_TEXT
+}
+
+// decoded returns the decoded form of a field, or its raw form in --raw
+// mode
+func (p *Printer) decoded(name, raw string) string {
+	if p.Raw {
+		return raw
+	}
+	return name
+}
+
+// limited returns how many of n list entries --limit lets through
+func (p *Printer) limited(n int) int {
+	if p.Limit > 0 && n > p.Limit {
+		return p.Limit
+	}
+	return n
+}
+
+// printOmitted notes how many entries of a list --limit left out
+func (p *Printer) printOmitted(omitted int, what string) {
+	if omitted > 0 {
+		p.Printf("  ... %d more %s not shown\n", omitted, what)
+	}
+}
+
+// symbolName returns a symbol name as it is to be shown, demangled when
+// asked to
+func (p *Printer) symbolName(name string) string {
+	if p.Demangle {
+		return elfreader.Demangle(name)
 	}
-	fmt.Println(string(jsonData))
+	return name
 }
 
-func PrintProgramHeaders(file *os.File, ehdr *Elf64Ehdr) {
-	file.Seek(int64(ehdr.Phoff), 0)
-	ColorPrint("Program Headers:\n")
+// colorSection highlights section names and headings
+func (p *Printer) colorSection(s string) string {
+	return p.paint(CATEGORY_SECTION, s)
+}
 
-	for i := 0; i < int(ehdr.Phnum); i++ {
-		var phdr Elf64Phdr
-		binary.Read(file, binary.LittleEndian, &phdr)
+// colorProgram highlights segment types and program header headings
+func (p *Printer) colorProgram(s string) string {
+	return p.paint(CATEGORY_PROGRAM, s)
+}
 
-		ColorPrint("  Type:               %d\n", phdr.Type)
-		ColorPrint("  Offset:             0x%x\n", phdr.Offset)
-		ColorPrint("  Virtual Address:    0x%x\n", phdr.Vaddr)
-		ColorPrint("  Physical Address:   0x%x\n", phdr.Paddr)
-		ColorPrint("  File Size:          %d\n", phdr.Filesz)
-		ColorPrint("  Memory Size:        %d\n", phdr.Memsz)
-		ColorPrint("  Flags:              0x%x\n", phdr.Flags)
-		ColorPrint("  Align:              %d\n\n", phdr.Align)
+// colorAddr formats and highlights an address, offset or other hex value.
+// The value is the last argument; a zero value is dimmed instead.
+func (p *Printer) colorAddr(format string, args ...interface{}) string {
+	if len(args) > 0 && isZero(args[len(args)-1]) {
+		return p.paint(CATEGORY_ZERO, fmt.Sprintf(format, args...))
 	}
+	return p.paint(CATEGORY_ADDRESS, fmt.Sprintf(format, args...))
 }
 
-func JSONOutputProgramHeaders(file *os.File, ehdr *Elf64Ehdr) {
-	file.Seek(int64(ehdr.Phoff), 0)
-	var phdrs []Elf64Phdr
+// colorSize formats a size or count, dimmed when it is zero so that empty
+// entries stand out from populated ones
+func (p *Printer) colorSize(format string, v uint64) string {
+	if v == 0 {
+		return p.paint(CATEGORY_ZERO, fmt.Sprintf(format, v))
+	}
+	return fmt.Sprintf(format, v)
+}
 
-	for i := 0; i < int(ehdr.Phnum); i++ {
-		var phdr Elf64Phdr
-		binary.Read(file, binary.LittleEndian, &phdr)
-		phdrs = append(phdrs, phdr)
+// isZero reports whether v is an integer equal to zero
+func isZero(v interface{}) bool {
+	switch n := v.(type) {
+	case int:
+		return n == 0
+	case int64:
+		return n == 0
+	case uint8:
+		return n == 0
+	case uint16:
+		return n == 0
+	case uint32:
+		return n == 0
+	case uint64:
+		return n == 0
 	}
+	return false
+}
 
-	jsonData, err := json.MarshalIndent(phdrs, "", "  ")
+// isTerminal reports whether the file is a character device such as a TTY
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error converting program headers to JSON: %v\n", err)
-		os.Exit(1)
+		return false
 	}
-	fmt.Println(string(jsonData))
+	return info.Mode()&os.ModeCharDevice != 0
 }
 
-func dumpStringTable(file *os.File, offset, size uint64) []byte {
-	file.Seek(int64(offset), 0)
-	strData := make([]byte, size)
-	binary.Read(file, binary.LittleEndian, &strData)
-	return strData
+// detectColor enables color only for terminals, and never when the NO_COLOR
+// environment variable is set (see https://no-color.org)
+func detectColor() bool {
+	if _, ok := os.LookupEnv("NO_COLOR"); ok {
+		return false
+	}
+	return isTerminal(os.Stdout)
 }
 
-func getString(data []byte, index uint32) string {
-	end := index
-	for end < uint32(len(data)) && data[end] != 0 {
-		end++
+// colorFromMode resolves a --color value into whether color is enabled
+func colorFromMode(mode string) (bool, error) {
+	switch mode {
+	case "auto":
+		return detectColor(), nil
+	case "always":
+		return true, nil
+	case "never":
+		return false, nil
 	}
-	return string(data[index:end])
+	return false, fmt.Errorf("invalid --color value %q (want auto, always or never)", mode)
 }
 
-func PrintSectionHeaders(file *os.File, ehdr *Elf64Ehdr) {
-	var shdrwns []Elf64ShdrWithName = MakeSectionHeaderWithName(file, ehdr)
+// headerFieldNames are the names --fields selects ELF header lines by, in
+// the order the lines are printed
+var headerFieldNames = []string{
+	"magic", "class", "data", "ident-version", "osabi", "abi-version", "padding", "type", "machine", "version",
+	"entry", "phoff", "shoff", "flags", "ehsize", "phentsize", "phnum", "shentsize", "shnum", "shstrndx",
+}
 
-	for i := 0; i < int(ehdr.Shnum); i++ {
-		ColorPrint("  [%2d] Name:               %s\n", i, shdrwns[i].Name)
-		ColorPrint("       Type:               %d\n", shdrwns[i].Type)
-		ColorPrint("       Flags:              0x%x\n", shdrwns[i].Flags)
-		ColorPrint("       Address:            0x%x\n", shdrwns[i].Addr)
-		ColorPrint("       Offset:             0x%x\n", shdrwns[i].Offset)
-		ColorPrint("       Size:               %d\n", shdrwns[i].Size)
-		ColorPrint("       Link:               %d\n", shdrwns[i].Link)
-		ColorPrint("       Info:               %d\n", shdrwns[i].Info)
-		ColorPrint("       Address Align:      %d\n", shdrwns[i].Addralign)
-		ColorPrint("       Entry Size:         %d\n\n", shdrwns[i].Entsize)
+// parseHeaderFields parses a comma-separated --fields list, matching the
+// names case-insensitively
+func parseHeaderFields(list string) (map[string]bool, error) {
+	fields := make(map[string]bool)
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		known := false
+		for _, field := range headerFieldNames {
+			known = known || field == name
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown field %q (want %s)", name, strings.Join(headerFieldNames, ", "))
+		}
+		fields[name] = true
 	}
+	return fields, nil
+}
+
+// showHeaderField reports whether the ELF header line of the field passes
+// the --fields filter
+func (p *Printer) showHeaderField(field string) bool {
+	return p.HeaderFields == nil || p.HeaderFields[field]
 }
 
-func MakeSectionHeaderWithName(file *os.File, ehdr *Elf64Ehdr) []Elf64ShdrWithName {
-	file.Seek(int64(ehdr.Shoff), 0)
-	ColorPrint("Section Headers:\n")
+// PrintELFHeader displays the ELF header information. The program headers
+// are consulted only to tell PIE executables from shared objects, so a
+// damaged program header table does not prevent the header from printing.
+func PrintELFHeader(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) {
+	phdrs, _ := elfreader.ReadProgramHeaders(file, ehdr, order)
+	sizes := elfreader.ExpectedHeaderSizes(ehdr)
 
-	// Load section headers into a slice
-	shdrs := make([]Elf64Shdr, ehdr.Shnum)
-	shdrwns := make([]Elf64ShdrWithName, ehdr.Shnum)
-	for i := 0; i < int(ehdr.Shnum); i++ {
-		binary.Read(file, binary.LittleEndian, &shdrs[i])
+	// The identification bytes after the magic number are shown on lines
+	// of their own, the unused ones as padding
+	identVersion := fmt.Sprint(ehdr.Ident[elfreader.EI_VERSION])
+	if ehdr.Ident[elfreader.EI_VERSION] == elfreader.EV_CURRENT {
+		identVersion = p.decoded(identVersion+" (current)", identVersion)
+	}
+	lines := []struct {
+		field string
+		text  string
+	}{
+		{"magic", "  Magic:                             " + hexBytes(ehdr.Ident[:elfreader.EI_CLASS])},
+		{"class", fmt.Sprintf("  Class:                             %s", p.decoded(elfreader.ClassName(ehdr.Ident[elfreader.EI_CLASS]), fmt.Sprint(ehdr.Ident[elfreader.EI_CLASS])))},
+		{"data", fmt.Sprintf("  Data:                              %s", p.decoded(elfreader.DataEncodingName(ehdr.Ident[elfreader.EI_DATA]), fmt.Sprint(ehdr.Ident[elfreader.EI_DATA])))},
+		{"ident-version", "  Version:                           " + identVersion},
+		{"osabi", fmt.Sprintf("  OS/ABI:                            %s", p.decoded(elfreader.OSABIName(ehdr.Ident[elfreader.EI_OSABI]), fmt.Sprint(ehdr.Ident[elfreader.EI_OSABI])))},
+		{"abi-version", fmt.Sprintf("  ABI Version:                       %d", ehdr.Ident[elfreader.EI_ABIVERSION])},
+		{"padding", "  Padding:                           " + hexBytes(ehdr.Ident[elfreader.EI_PAD:])},
+		{"type", fmt.Sprintf("  Type:                              %s", p.decoded(elfreader.FileTypeName(ehdr, phdrs), fmt.Sprint(ehdr.Type)))},
+		{"machine", fmt.Sprintf("  Machine:                           %s", p.decoded(elfreader.MachineName(ehdr.Machine), fmt.Sprint(ehdr.Machine)))},
+		{"version", fmt.Sprintf("  Version:                           %s", p.colorAddr("0x%x", ehdr.Version))},
+		{"entry", fmt.Sprintf("  Entry point address:               %s", p.colorAddr("0x%x", ehdr.Entry))},
+		{"phoff", fmt.Sprintf("  Start of program headers:          %d (bytes into file)", ehdr.Phoff)},
+		{"shoff", fmt.Sprintf("  Start of section headers:          %d (bytes into file)", ehdr.Shoff)},
+		{"flags", fmt.Sprintf("  Flags:                             %s", p.colorAddr("0x%x", ehdr.Flags))},
+		{"ehsize", fmt.Sprintf("  Size of this header:               %d (bytes)%s", ehdr.Ehsize, unexpectedSize(ehdr.Ehsize, sizes.Ehsize, true))},
+		{"phentsize", fmt.Sprintf("  Size of program headers:           %d (bytes)%s", ehdr.Phentsize, unexpectedSize(ehdr.Phentsize, sizes.Phentsize, ehdr.Phnum != 0))},
+		{"phnum", fmt.Sprintf("  Number of program headers:         %d", ehdr.Phnum)},
+		{"shentsize", fmt.Sprintf("  Size of section headers:           %d (bytes)%s", ehdr.Shentsize, unexpectedSize(ehdr.Shentsize, sizes.Shentsize, ehdr.Shoff != 0))},
+		{"shnum", fmt.Sprintf("  Number of section headers:         %d", ehdr.Shnum)},
+		{"shstrndx", fmt.Sprintf("  Section header string table index: %d", ehdr.Shstrndx)},
 	}
 
-	// Load the section header string table
-	stringTable := dumpStringTable(file, shdrs[ehdr.Shstrndx].Offset, shdrs[ehdr.Shstrndx].Size)
+	p.Printf("This image displays information about a machine and operating system:\n")
+	for _, line := range lines {
+		if p.showHeaderField(line.field) {
+			p.Printf("%s\n", line.text)
+		}
+	}
+}
 
-	for i := 0; i < int(ehdr.Shnum); i++ {
-		sectionName := getString(stringTable, shdrs[i].Name)
-		shdrwns[i].Name = sectionName
-		shdrwns[i].Type = shdrs[i].Type
-		shdrwns[i].Flags = shdrs[i].Flags
-		shdrwns[i].Addr = shdrs[i].Addr
-		shdrwns[i].Offset = shdrs[i].Offset
-		shdrwns[i].Size = shdrs[i].Size
-		shdrwns[i].Link = shdrs[i].Link
-		shdrwns[i].Info = shdrs[i].Info
-		shdrwns[i].Addralign = shdrs[i].Addralign
-		shdrwns[i].Entsize = shdrs[i].Entsize
+// hexBytes formats bytes as space-separated hex pairs
+func hexBytes(b []byte) string {
+	hex := make([]string, 0, len(b))
+	for _, c := range b {
+		hex = append(hex, fmt.Sprintf("%02x", c))
 	}
+	return strings.Join(hex, " ")
+}
 
-	return shdrwns
+// unexpectedSize notes a header size that differs from the one the class
+// of the file calls for. Files without a table may leave its entry size 0,
+// so the size of a table that is not present is never noted.
+func unexpectedSize(size, expected uint16, present bool) string {
+	if !present || size == expected {
+		return ""
+	}
+	return fmt.Sprintf(" [expected %d]", expected)
 }
 
-func JSONOutputSectionHeaders(file *os.File, ehdr *Elf64Ehdr) {
-	var shdrwns []Elf64ShdrWithName = MakeSectionHeaderWithName(file, ehdr)
+func PrintProgramHeaders(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	phdrs, err := elfreader.ReadProgramHeaders(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(phdrs) != 0 && len(filterSegments(p, phdrs)) == 0 {
+		p.Printf("There are no %s segments in this file.\n", elfreader.PhdrTypeName(*p.SegmentType))
+		return nil
+	}
+	p.Printf("%s\n", p.colorProgram("Program Headers:"))
+
+	for _, phdr := range phdrs {
+		if !p.showSegment(phdr) {
+			continue
+		}
+		p.Printf("  Type:               %s\n", p.colorProgram(p.decoded(elfreader.PhdrTypeName(phdr.Type), fmt.Sprintf("0x%x", phdr.Type))))
+		if phdr.Type == elfreader.PT_INTERP && !p.Raw {
+			interp, err := elfreader.ReadInterpreter(file, phdr)
+			if err != nil {
+				return err
+			}
+			p.Printf("  [Requesting program interpreter: %s]\n", interp)
+		}
+		// The TLS template is copied into each thread's block: the file part
+		// from .tdata, the rest zeroed as .tbss
+		if phdr.Type == elfreader.PT_TLS && !p.Raw {
+			zeroed := uint64(0)
+			if phdr.Memsz > phdr.Filesz {
+				zeroed = phdr.Memsz - phdr.Filesz
+			}
+			p.Printf("  [Thread-local storage: %d bytes per thread, %d initialized (.tdata), %d zeroed (.tbss), aligned to %d]\n",
+				phdr.Memsz, phdr.Filesz, zeroed, phdr.Align)
+		}
+		p.Printf("  Offset:             %s\n", p.colorAddr("0x%x", phdr.Offset))
+		p.Printf("  Virtual Address:    %s\n", p.colorAddr("0x%x", phdr.Vaddr))
+		p.Printf("  Physical Address:   %s\n", p.colorAddr("0x%x", phdr.Paddr))
+		p.Printf("  File Size:          %s\n", p.colorSize("%d", phdr.Filesz))
+		p.Printf("  Memory Size:        %s\n", p.colorSize("%d", phdr.Memsz))
+		flags := p.colorAddr("0x%x", phdr.Flags)
+		p.Printf("  Flags:              %s\n", p.decoded(elfreader.PhdrFlagsString(phdr.Flags)+" ("+flags+")", flags))
+		p.Printf("  Align:              %d\n\n", phdr.Align)
+	}
 
-	jsonData, err := json.MarshalIndent(shdrwns, "", "  ")
+	if len(phdrs) == 0 || len(shdrwns) == 0 {
+		return nil
+	}
+	p.Printf(" %s\n", p.colorSection("Section to Segment mapping:"))
+	p.Printf("  Segment Sections...\n")
+	for i, phdr := range phdrs {
+		if !p.showSegment(phdr) {
+			continue
+		}
+		p.Printf("   %02d     ", i)
+		for _, shdrwn := range shdrwns {
+			if elfreader.SectionInSegment(shdrwn, phdr) {
+				p.Printf("%s ", p.colorSection(shdrwn.Name))
+			}
+		}
+		p.Printf("\n")
+	}
+	return nil
+}
+
+func PrintSectionHeaders(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error converting program headers to JSON: %v\n", err)
-		os.Exit(1)
+		return err
+	}
+	indexes := sectionOrder(p, shdrwns)
+	if len(indexes) == 0 {
+		return nil
 	}
-	fmt.Println(string(jsonData))
+	total := len(indexes)
+	indexes = indexes[:p.limited(total)]
+	p.Printf("%s\n", p.colorSection("Section Headers:"))
+	if !p.VerboseSections {
+		printSectionTable(p, ehdr, shdrwns, indexes)
+		p.printOmitted(total-len(indexes), "section headers")
+		return nil
+	}
+
+	for _, i := range indexes {
+		p.Printf("  [%2d] Name:               %s\n", i, p.colorSection(shdrwns[i].Name))
+		flags := p.colorAddr("0x%x", shdrwns[i].Flags)
+		p.Printf("       Type:               %s\n", p.decoded(elfreader.SectionTypeName(shdrwns[i].Type), fmt.Sprintf("0x%x", shdrwns[i].Type)))
+		p.Printf("       Flags:              %s\n", p.decoded(elfreader.SectionFlagsString(shdrwns[i].Flags)+" ("+flags+")", flags))
+		p.Printf("       Address:            %s\n", p.colorAddr("0x%x", shdrwns[i].Addr))
+		p.Printf("       Offset:             %s\n", p.colorAddr("0x%x", shdrwns[i].Offset))
+		p.Printf("       Size:               %s\n", p.colorSize("%d", shdrwns[i].Size))
+		p.Printf("       Link:               %d\n", shdrwns[i].Link)
+		p.Printf("       Info:               %d\n", shdrwns[i].Info)
+		p.Printf("       Address Align:      %d\n", shdrwns[i].Addralign)
+		p.Printf("       Entry Size:         %d\n\n", shdrwns[i].Entsize)
+	}
+	p.printOmitted(total-len(indexes), "section headers")
+	return nil
 }
 
-func ReadELFHeader(file *os.File) (*Elf64Ehdr, error) {
-	ehdr := new(Elf64Ehdr)
-	err := binary.Read(file, binary.LittleEndian, ehdr)
+// sectionNames returns the names of the sections in index order, leaving
+// out the null section and those --section-name does not match
+func sectionNames(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) ([]string, error) {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
 	if err != nil {
 		return nil, err
 	}
-	return ehdr, nil
+	names := []string{}
+	for i := 1; i < len(shdrwns); i++ {
+		if p.showSection(shdrwns[i].Name) {
+			names = append(names, shdrwns[i].Name)
+		}
+	}
+	return names, nil
+}
+
+// PrintSectionList prints one section name per line, for piping into
+// other tools
+func PrintSectionList(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	names, err := sectionNames(p, file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		p.Printf("%s\n", p.colorSection(name))
+	}
+	return nil
+}
+
+// showSegment reports whether the segment passes the --segment-type filter
+func (p *Printer) showSegment(phdr elfreader.Elf64Phdr) bool {
+	return p.SegmentType == nil || phdr.Type == *p.SegmentType
+}
+
+// filterSegments returns the program headers that pass the --segment-type
+// filter
+func filterSegments(p *Printer, phdrs []elfreader.Elf64Phdr) []elfreader.Elf64Phdr {
+	if p.SegmentType == nil {
+		return phdrs
+	}
+	matched := []elfreader.Elf64Phdr{}
+	for _, phdr := range phdrs {
+		if p.showSegment(phdr) {
+			matched = append(matched, phdr)
+		}
+	}
+	return matched
+}
+
+// showSection reports whether the section passes the --section-name filter
+func (p *Printer) showSection(name string) bool {
+	return p.SectionFilter == nil || p.SectionFilter.MatchString(name)
+}
+
+// Section header orders selectable with --sort-sections
+const (
+	SORT_INDEX  = "index"
+	SORT_SIZE   = "size"
+	SORT_ADDR   = "addr"
+	SORT_OFFSET = "offset"
+	SORT_NAME   = "name"
+)
+
+// sectionOrder returns the indexes of the section headers that pass the
+// --section-name filter, in the order chosen with --sort-sections. Sections
+// that compare equal keep their index order.
+func sectionOrder(p *Printer, shdrwns []elfreader.Elf64ShdrWithName) []int {
+	indexes := []int{}
+	for i, shdrwn := range shdrwns {
+		if p.showSection(shdrwn.Name) {
+			indexes = append(indexes, i)
+		}
+	}
+
+	less := func(a, b elfreader.Elf64ShdrWithName) bool { return false }
+	switch p.SectionSort {
+	case SORT_SIZE:
+		less = func(a, b elfreader.Elf64ShdrWithName) bool { return a.Size < b.Size }
+	case SORT_ADDR:
+		less = func(a, b elfreader.Elf64ShdrWithName) bool { return a.Addr < b.Addr }
+	case SORT_OFFSET:
+		less = func(a, b elfreader.Elf64ShdrWithName) bool { return a.Offset < b.Offset }
+	case SORT_NAME:
+		less = func(a, b elfreader.Elf64ShdrWithName) bool { return a.Name < b.Name }
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		a, b := shdrwns[indexes[i]], shdrwns[indexes[j]]
+		if less(a, b) {
+			return true
+		}
+		if less(b, a) {
+			return false
+		}
+		return indexes[i] < indexes[j]
+	})
+	return indexes
+}
+
+// filterSections returns the section headers that pass the --section-name
+// filter, in the order chosen with --sort-sections
+func filterSections(p *Printer, shdrwns []elfreader.Elf64ShdrWithName) []elfreader.Elf64ShdrWithName {
+	matched := []elfreader.Elf64ShdrWithName{}
+	for _, i := range sectionOrder(p, shdrwns) {
+		matched = append(matched, shdrwns[i])
+	}
+	return matched
+}
+
+// SECTION_NAME_WIDTH is the longest section name shown in full outside of
+// wide mode, the same limit readelf uses
+const SECTION_NAME_WIDTH = 17
+
+// shortenName cuts a name longer than width so that it ends in "[...]"
+func shortenName(name string, width int) string {
+	if len(name) <= width {
+		return name
+	}
+	return name[:width-5] + "[...]"
+}
+
+// printSectionTable displays the sections at the given indexes one per line
+// like readelf -S. Without wide mode, long section names are shortened to
+// keep the table narrow.
+func printSectionTable(p *Printer, ehdr *elfreader.Elf64Ehdr, shdrwns []elfreader.Elf64ShdrWithName, indexes []int) {
+	addrWidth := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		addrWidth = 8
+	}
+
+	t := newTable("[Nr]", "Name", "Type", "Address", "Offset", "Size", "ES", "Flg", "Lk", "Inf", "Al")
+	t.alignRight(0, 7, 8, 9, 10)
+	for _, i := range indexes {
+		shdrwn := shdrwns[i]
+		name := shdrwn.Name
+		if !p.Wide {
+			name = shortenName(name, SECTION_NAME_WIDTH)
+		}
+		t.addRow(
+			fmt.Sprintf("[%2d]", i),
+			p.colorSection(name),
+			p.decoded(elfreader.SectionTypeName(shdrwn.Type), fmt.Sprintf("0x%x", shdrwn.Type)),
+			p.colorAddr("%0*x", addrWidth, shdrwn.Addr),
+			p.colorAddr("%06x", shdrwn.Offset),
+			p.colorSize("%06x", shdrwn.Size),
+			fmt.Sprintf("%02x", shdrwn.Entsize),
+			p.decoded(elfreader.SectionFlagsString(shdrwn.Flags), fmt.Sprintf("%x", shdrwn.Flags)),
+			fmt.Sprintf("%d", shdrwn.Link),
+			fmt.Sprintf("%d", shdrwn.Info),
+			fmt.Sprintf("%d", shdrwn.Addralign),
+		)
+	}
+	t.print(p, "  ")
+}
+
+// openInput opens the named file for reading. Standard input, named "-", is
+// not seekable, so it is read fully into memory first.
+func openInput(name string) (io.ReaderAt, error) {
+	if name != "-" {
+		return os.Open(name)
+	}
+
+	data, err := ioutil.ReadAll(os.Stdin)
+	if err != nil {
+		return nil, fmt.Errorf("reading standard input: %w", err)
+	}
+	if len(data) == 0 {
+		return nil, errors.New("standard input is empty")
+	}
+	return bytes.NewReader(data), nil
+}
+
+// options holds the dumps and output format selected on the command line
+type options struct {
+	showHeader         bool
+	showProgramHeaders bool
+	showSectionHeaders bool
+	listSections       bool
+	showSymbols        bool
+	showDynamic        bool
+	showRelocations    bool
+	showNotes          bool
+	showHashTables     bool
+	showHistogram      bool
+	showVersionInfo    bool
+	showEHFrameHdr     bool
+	showIdentity       bool
+	showNeeded         bool
+	showSummary        bool
+	showCounts         bool
+	checkLayout        bool
+	validate           bool
+	showAddresses      bool
+	showMemoryMap      bool
+	showDebugLink      bool
+	stringDump         string
+	hexDump            string
+	extract            string
+	extractOut         string
+	entryBytes         uint64
+	lookupAddr         *uint64
+	lookupOffset       *uint64
+	symPattern         *regexp.Regexp
+	format             string
+	offset             uint64
+	forceClass         byte
+	forceEncoding      byte
+}
+
+// selectsDump reports whether any dump is selected. The output format, the
+// offset of the ELF file and the forced class and byte order only change how
+// the dumps are read and printed.
+func (opts *options) selectsDump() bool {
+	return opts.showHeader || opts.showProgramHeaders || opts.showSectionHeaders || opts.listSections || opts.showSymbols ||
+		opts.showDynamic || opts.showRelocations || opts.showNotes || opts.showHashTables || opts.showHistogram || opts.showVersionInfo ||
+		opts.showEHFrameHdr || opts.showIdentity || opts.showNeeded || opts.showSummary || opts.showCounts || opts.checkLayout ||
+		opts.validate || opts.showAddresses || opts.showMemoryMap || opts.showDebugLink || opts.stringDump != "" || opts.hexDump != "" ||
+		opts.extract != "" || opts.entryBytes != 0 || opts.lookupAddr != nil || opts.lookupOffset != nil || opts.symPattern != nil
+}
+
+// dumpFile prints every selected dump of one file
+func dumpFile(p *Printer, fileName string, opts *options) error {
+	file, err := openInput(fileName)
+	if err != nil {
+		return fmt.Errorf("opening file: %w", err)
+	}
+	if closer, ok := file.(io.Closer); ok {
+		defer closer.Close()
+	}
+	// An ELF file embedded in a larger one is read as if it started at the
+	// offset, so every table offset inside it stays relative to its header
+	if opts.offset != 0 {
+		if opts.offset > math.MaxInt64 {
+			return fmt.Errorf("offset 0x%x is out of range", opts.offset)
+		}
+		fileSize := elfreader.FileSize(file)
+		if fileSize >= 0 && opts.offset >= uint64(fileSize) {
+			return fmt.Errorf("offset 0x%x is beyond the end of the file (%d bytes)", opts.offset, fileSize)
+		}
+		// A file of unknown size is read up to wherever it ends
+		size := fileSize - int64(opts.offset)
+		if fileSize < 0 {
+			size = math.MaxInt64 - int64(opts.offset)
+		}
+		file = io.NewSectionReader(file, int64(opts.offset), size)
+	}
+
+	if elfreader.IsArchive(file) {
+		return dumpArchive(p, fileName, file, opts)
+	}
+	return dumpELF(p, file, opts)
+}
+
+// dumpArchive prints the selected dumps of every member of a static
+// library. In text output each member is named on a "File: lib.a(member.o)"
+// line, as readelf does. Members that are not ELF objects, such as LLVM
+// bitcode, are skipped with a warning. A member that cannot be read is
+// reported the same way and the rest are still dumped, but the archive
+// then fails.
+func dumpArchive(p *Printer, fileName string, file io.ReaderAt, opts *options) error {
+	members, err := elfreader.ReadArchive(file)
+	if err != nil {
+		return err
+	}
+	printed, failed := 0, 0
+	for _, member := range members {
+		if !elfreader.IsELF(member.Reader) {
+			p.warn("%s(%s) is not an ELF object, skipped", fileName, member.Name)
+			continue
+		}
+		if printed > 0 {
+			printSeparator(p, opts.format)
+		}
+		printed++
+		if opts.format == FORMAT_TEXT {
+			p.Printf("File: %s(%s)\n", fileName, member.Name)
+		}
+		if err := dumpELF(p, member.Reader, opts); err != nil {
+			p.warn("%s(%s): %v", fileName, member.Name, err)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d archive members could not be read", failed, len(members))
+	}
+	return nil
+}
+
+// dumpELF prints every selected dump of one ELF file
+func dumpELF(p *Printer, file io.ReaderAt, opts *options) error {
+	elf, err := elfreader.NewFileForced(file, opts.forceClass, opts.forceEncoding)
+	if err != nil {
+		return fmt.Errorf("reading ELF header: %w", err)
+	}
+	elf.Warn = p.Warn
+	file = elf
+	ehdr, order := elf.Ehdr, elf.Order
+
+	// Every reader works at its table's absolute offset, so the dumps below
+	// can share the file in any combination. Passing them the elfreader.File
+	// lets them share a single read of the program and section headers.
+	dumps := []struct {
+		enabled bool
+		text    func() error
+		data    func() (interface{}, error)
+	}{
+		{opts.showHeader, func() error { PrintELFHeader(p, file, ehdr, order); return nil }, func() (interface{}, error) { return ehdr, nil }},
+		{opts.showProgramHeaders, func() error { return PrintProgramHeaders(p, file, ehdr, order) }, func() (interface{}, error) {
+			phdrs, err := elfreader.ReadProgramHeaders(file, ehdr, order)
+			return filterSegments(p, phdrs), err
+		}},
+		{opts.showSectionHeaders, func() error { return PrintSectionHeaders(p, file, ehdr, order) }, func() (interface{}, error) {
+			shdrwns, err := elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+			return filterSections(p, shdrwns), err
+		}},
+		{opts.listSections, func() error { return PrintSectionList(p, file, ehdr, order) }, func() (interface{}, error) { return sectionNames(p, file, ehdr, order) }},
+		{opts.showSymbols, func() error { return PrintSymbols(p, file, ehdr, order) }, func() (interface{}, error) { return symbolTables{file, ehdr, order}, nil }},
+		{opts.showDynamic, func() error { return PrintDynamic(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadDynamic(file, ehdr, order) }},
+		{opts.showRelocations, func() error { return PrintRelocations(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadRelocations(file, ehdr, order) }},
+		{opts.showNotes, func() error { return PrintNotes(p, file, ehdr, order) }, nil},
+		{opts.showHashTables, func() error { return PrintHashTables(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadHashTables(file, ehdr, order) }},
+		{opts.showHistogram, func() error { return PrintHistogram(p, file, ehdr, order) }, nil},
+		{opts.showVersionInfo, func() error { return PrintVersionInfo(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadVersionInfo(file, ehdr, order) }},
+		{opts.showEHFrameHdr, func() error { return PrintEHFrameHdr(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadEHFrameHdr(file, ehdr, order) }},
+		{opts.showIdentity, func() error { return PrintIdentity(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadIdentity(file, ehdr, order) }},
+		{opts.showNeeded, func() error { return PrintDependencies(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadDependencies(file, ehdr, order) }},
+		{opts.showSummary, func() error { return PrintSummary(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadSummary(file, ehdr, order) }},
+		{opts.showCounts, func() error { return PrintCounts(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadCounts(file, ehdr, order) }},
+		{opts.showAddresses, func() error { return PrintAddresses(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadAddresses(file, ehdr, order) }},
+		{opts.showMemoryMap, func() error { return PrintMemoryMap(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadMemoryMap(file, ehdr, order) }},
+		{opts.showDebugLink, func() error { return PrintDebugLink(p, file, ehdr, order) }, func() (interface{}, error) { return elfreader.ReadDebugLink(file, ehdr, order) }},
+		{opts.checkLayout, func() error { return PrintLayoutCheck(p, file, ehdr, order) }, nil},
+		{opts.validate, func() error { return PrintValidation(p, file, ehdr, order) }, nil},
+		{opts.stringDump != "", func() error { return PrintStringDump(p, file, ehdr, order, opts.stringDump) }, nil},
+		{opts.hexDump != "", func() error { return PrintHexDump(p, file, ehdr, order, opts.hexDump) }, nil},
+		{opts.extract != "", func() error { return extractSection(p, file, ehdr, order, opts.extract, opts.extractOut) }, nil},
+		{opts.entryBytes != 0, func() error { return PrintEntryBytes(p, file, ehdr, order, opts.entryBytes) }, func() (interface{}, error) { return elfreader.ReadEntryBytes(file, ehdr, order, opts.entryBytes) }},
+		{opts.lookupAddr != nil, func() error { return PrintAddressLookup(p, file, ehdr, order, *opts.lookupAddr) }, func() (interface{}, error) {
+			return elfreader.LookupAddress(file, ehdr, order, *opts.lookupAddr)
+		}},
+		{opts.lookupOffset != nil, func() error { return PrintOffsetLookup(p, file, ehdr, order, *opts.lookupOffset) }, func() (interface{}, error) {
+			return elfreader.LookupOffset(file, ehdr, order, *opts.lookupOffset)
+		}},
+		{opts.symPattern != nil, func() error { return PrintSymbolSearch(p, file, ehdr, order, opts.symPattern) }, func() (interface{}, error) { return findSymbols(file, ehdr, order, opts.symPattern) }},
+	}
+
+	printed := false
+	for _, dump := range dumps {
+		if !dump.enabled {
+			continue
+		}
+
+		// Dumps without a structured form fall back to text
+		var err error
+		if opts.format != FORMAT_TEXT && dump.data != nil {
+			if printed {
+				printSeparator(p, opts.format)
+			}
+			var v interface{}
+			v, err = dump.data()
+			if err == nil {
+				err = MarshalOutput(p.Out, opts.format, v)
+			}
+		} else {
+			if printed {
+				printSeparator(p, FORMAT_TEXT)
+			}
+			err = dump.text()
+		}
+		printed = true
+
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printSeparator separates two consecutive dumps in the given format
+func printSeparator(p *Printer, format string) {
+	switch format {
+	case FORMAT_YAML:
+		fmt.Fprintln(p.Out, "---")
+	case FORMAT_TEXT:
+		p.Printf("\n")
+	case FORMAT_CSV:
+		// Each table has its own header line
+		fmt.Fprintln(p.Out)
+	}
+}
+
+// parseHex parses an address or offset given in hex, with or without 0x
+func parseHex(s string) (uint64, error) {
+	return strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X"), 16, 64)
+}
+
+// flushOutput writes out what is buffered for standard output. A failed
+// write sticks to the writer, so a flush that fails part way through is
+// reported again by the last one.
+func flushOutput(out *bufio.Writer) error {
+	if err := out.Flush(); err != nil {
+		return fmt.Errorf("writing output: %w", err)
+	}
+	return nil
 }
 
 func main() {
-	if len(os.Args) != 3 {
-		fmt.Fprintf(os.Stderr, "Usage: %s <-h|-l|-S|-j|-jh|-jl|-jS> <elf-file>\n", os.Args[0])
+	var opts options
+	flag.BoolVar(&opts.showHeader, "h", false, "display the ELF file header")
+	flag.BoolVar(&opts.showProgramHeaders, "l", false, "display the program headers")
+	flag.BoolVar(&opts.showSectionHeaders, "S", false, "display the section headers")
+	flag.BoolVar(&opts.listSections, "list-sections", false, "print the name of each section, one per line")
+	flag.BoolVar(&opts.showSymbols, "s", false, "display the symbol tables")
+	flag.BoolVar(&opts.showDynamic, "d", false, "display the dynamic section")
+	flag.BoolVar(&opts.showRelocations, "r", false, "display the relocations")
+	flag.BoolVar(&opts.showNotes, "n", false, "display the notes")
+	flag.BoolVar(&opts.showHashTables, "hash", false, "display the structure of the symbol hash tables")
+	flag.BoolVar(&opts.showHistogram, "I", false, "display a histogram of hash bucket list lengths")
+	flag.BoolVar(&opts.showVersionInfo, "V", false, "display the symbol version sections")
+	flag.BoolVar(&opts.showEHFrameHdr, "eh-frame-hdr", false, "decode the .eh_frame_hdr unwind table")
+	flag.BoolVar(&opts.showIdentity, "id", false, "display a build ID and identity summary")
+	flag.BoolVar(&opts.showNeeded, "needed", false, "list the needed shared libraries and search paths")
+	flag.BoolVar(&opts.showSummary, "summary", false, "display an overview of the file's headers and sizes")
+	flag.BoolVar(&opts.showCounts, "count", false, "print the number of program headers, section headers, symbols and relocations as key=value pairs")
+	flag.BoolVar(&opts.showAddresses, "addrs", false, "print the entry point, load base and program header addresses")
+	flag.BoolVar(&opts.showMemoryMap, "map", false, "display the memory layout of the loadable segments")
+	flag.BoolVar(&opts.showDebugLink, "debuglink", false, "display the separate debug file named by .gnu_debuglink")
+	flag.BoolVar(&opts.checkLayout, "check", false, "report overlapping sections and segments, failing if any are found")
+	flag.BoolVar(&opts.validate, "validate", false, "check the file against the ELF specification, failing if any errors are found")
+	raw := flag.Bool("raw", false, "show header fields as plain numbers, without names or flag letters")
+	demangle := flag.Bool("C", false, "decode C++ symbol names into their source form")
+	flag.BoolVar(demangle, "demangle", false, "same as -C")
+	wide := flag.Bool("W", false, "print one line per section or symbol, with columns as wide as their longest value")
+	flag.BoolVar(wide, "wide", false, "same as -W")
+	sectionName := flag.String("section-name", "", "show only the section headers whose name matches the `regexp`")
+	sectionSort := flag.String("sort-sections", SORT_INDEX, "list section headers by `key`: index, size, addr, offset or name")
+	segmentType := flag.String("segment-type", "", "show only the program headers of the given `type`, by name (LOAD) or number")
+	headerFields := flag.String("fields", "", "show only the ELF header lines of the comma-separated `names`, such as entry,type,machine")
+	verboseSections := flag.Bool("verbose-sections", false, "list each section header field on a line of its own")
+	diff := flag.Bool("diff", false, "compare the headers, sections and segments of two files")
+	limit := flag.Int("limit", 0, "print at most `n` entries of each symbol, relocation or section list in text output")
+	showAll := flag.Bool("a", false, "equivalent to -h -l -S -s -d -r -n")
+	flag.StringVar(&opts.stringDump, "p", "", "display the contents of the named `section` as strings")
+	flag.StringVar(&opts.hexDump, "x", "", "display the contents of the named `section` as bytes")
+	flag.StringVar(&opts.extract, "extract", "", "write the raw contents of the named `section` to standard output or to the --out file")
+	flag.StringVar(&opts.extractOut, "out", "", "write the section extracted with --extract to `path`")
+	symPattern := flag.String("sym", "", "list the symbols of .symtab and .dynsym whose name matches the `regexp`, failing if none do")
+	addr2section := flag.String("addr2section", "", "report the sections and segments containing the virtual `address`, given in hex")
+	offset2vaddr := flag.String("file-offset-to-vaddr", "", "report the virtual address the file `offset`, given in hex, is loaded at")
+	flag.Uint64Var(&opts.entryBytes, "entry-disasm", 0, "display the first `n` bytes of code at the entry point")
+	flag.Uint64Var(&opts.offset, "o", 0, "read the ELF file starting at byte `offset` into the input")
+	flag.Uint64Var(&opts.offset, "offset", 0, "same as -o")
+	forceClass := flag.String("force-class", "", "expert override: read the file as ELF `class` 32 or 64 whatever its EI_CLASS byte says")
+	forceEndian := flag.String("force-endian", "", "expert override: read the file with the given byte `order`, little or big, whatever its EI_DATA byte says")
+	flag.StringVar(&opts.format, "format", FORMAT_TEXT, "output `format`: text, json, json-compact, yaml, xml or csv")
+	jsonOutput := flag.Bool("j", false, "same as --format=json")
+	flag.BoolVar(jsonOutput, "json", false, "same as --format=json")
+	jsonCompact := flag.Bool("json-compact", false, "same as --format=json-compact, which prints each JSON document on one line")
+	jsonHeader := flag.Bool("jh", false, "same as -j -h")
+	jsonProgramHeaders := flag.Bool("jl", false, "same as -j -l")
+	jsonSectionHeaders := flag.Bool("jS", false, "same as -j -S")
+	colorMode := flag.String("color", "auto", "colorize output: auto, always or never")
+	noColor := flag.Bool("no-color", false, "same as --color=never")
+	colorDepth := flag.String("color-depth", COLOR_DEPTH_AUTO, "colors to use: auto (from $COLORTERM), 8, 256 or truecolor")
+	var colorMaps colorMapFlag
+	flag.Var(&colorMaps, "color-map", "override colors with `category=color` entries (section, program, address, added, removed, zero); repeatable, also read from $"+COLORS_ENV)
+	usePager := flag.Bool("pager", false, "page the output through $PAGER, or "+DEFAULT_PAGER+", when it goes to a terminal")
+	showVersion := flag.Bool("version", false, "display the program version and exit")
+	flag.BoolVar(showVersion, "v", false, "same as --version")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options] <elf-file>...\n       (use - to read a file from standard input)\n", os.Args[0])
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if *showVersion {
+		printVersion(os.Stdout)
+		return
+	}
+
+	if *showAll {
+		opts.showHeader = true
+		opts.showProgramHeaders = true
+		opts.showSectionHeaders = true
+		opts.showSymbols = true
+		opts.showDynamic = true
+		opts.showRelocations = true
+		opts.showNotes = true
+	}
+
+	// Keep the old combined spellings working
+	if *jsonHeader || *jsonProgramHeaders || *jsonSectionHeaders {
+		*jsonOutput = true
+		opts.showHeader = opts.showHeader || *jsonHeader
+		opts.showProgramHeaders = opts.showProgramHeaders || *jsonProgramHeaders
+		opts.showSectionHeaders = opts.showSectionHeaders || *jsonSectionHeaders
+	}
+
+	// The lookups and the symbol pattern are parsed into opts further down
+	lookups := *symPattern != "" || *addr2section != "" || *offset2vaddr != ""
+	selected := opts.selectsDump() || lookups
+	if flag.NArg() == 0 || !selected && !*diff {
+		flag.Usage()
 		os.Exit(1)
 	}
 
-	option := os.Args[1]
-	fileName := os.Args[2]
+	if opts.extractOut != "" && opts.extract == "" {
+		fmt.Fprintf(os.Stderr, "Error: --out is only used with --extract\n")
+		os.Exit(1)
+	}
+	// Raw bytes on standard output cannot share it with anything else
+	if opts.extract != "" && opts.extractOut == "" {
+		only := opts
+		only.extract = ""
+		if flag.NArg() != 1 || *diff || only.selectsDump() || lookups {
+			fmt.Fprintf(os.Stderr, "Error: --extract without --out takes one file and no other dump\n")
+			os.Exit(1)
+		}
+	}
 
-	file, err := os.Open(fileName)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error opening file: %v\n", err)
+	switch *forceClass {
+	case "":
+	case "32":
+		opts.forceClass = elfreader.ELFCLASS32
+	case "64":
+		opts.forceClass = elfreader.ELFCLASS64
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --force-class value %q (want 32 or 64)\n", *forceClass)
+		os.Exit(1)
+	}
+	switch *forceEndian {
+	case "":
+	case "little":
+		opts.forceEncoding = elfreader.ELFDATA2LSB
+	case "big":
+		opts.forceEncoding = elfreader.ELFDATA2MSB
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --force-endian value %q (want little or big)\n", *forceEndian)
 		os.Exit(1)
 	}
-	defer file.Close()
 
-	ehdr, err := ReadELFHeader(file)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error reading ELF header: %v\n", err)
+	if *jsonOutput {
+		opts.format = FORMAT_JSON
+	}
+	if *jsonCompact {
+		opts.format = FORMAT_JSON_COMPACT
+	}
+	switch opts.format {
+	case FORMAT_TEXT, FORMAT_JSON, FORMAT_JSON_COMPACT, FORMAT_YAML, FORMAT_XML:
+	case FORMAT_CSV:
+		if opts.showHeader || opts.listSections || opts.showSymbols || opts.showDynamic || opts.showRelocations || opts.showNotes ||
+			opts.showHashTables || opts.showHistogram || opts.showVersionInfo || opts.showEHFrameHdr ||
+			opts.showIdentity || opts.showNeeded || opts.showSummary || opts.showCounts || opts.showAddresses || opts.showMemoryMap || opts.showDebugLink || opts.checkLayout || opts.validate || opts.stringDump != "" || opts.hexDump != "" || opts.extract != "" || opts.entryBytes != 0 || *symPattern != "" || *addr2section != "" || *offset2vaddr != "" {
+			fmt.Fprintf(os.Stderr, "Error: --format=csv only supports -l and -S\n")
+			os.Exit(1)
+		}
+	default:
+		fmt.Fprintf(os.Stderr, "Error: invalid --format value %q (want text, json, json-compact, yaml, xml or csv)\n", opts.format)
 		os.Exit(1)
 	}
 
-	switch option {
-	case "-h":
-		PrintELFHeader(ehdr)
-	case "-l":
-		PrintProgramHeaders(file, ehdr)
-	case "-S":
-		PrintSectionHeaders(file, ehdr)
-	case "-jh":
-		JSONOutputELFHeader(ehdr)
-	case "-jl":
-		JSONOutputProgramHeaders(file, ehdr)
-	case "-jS":
-		JSONOutputSectionHeaders(file, ehdr)
+	if *noColor {
+		*colorMode = "never"
+	}
+	color, err := colorFromMode(*colorMode)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	depth, err := colorDepthFromMode(*colorDepth)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	palette, err := loadPalette(depth, colorMaps)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	switch *sectionSort {
+	case SORT_INDEX, SORT_SIZE, SORT_ADDR, SORT_OFFSET, SORT_NAME:
 	default:
-		fmt.Fprintf(os.Stderr, "Invalid option: %s\n", option)
+		fmt.Fprintf(os.Stderr, "Error: invalid --sort-sections value %q (want index, size, addr, offset or name)\n", *sectionSort)
+		os.Exit(1)
+	}
+	var sectionFilter *regexp.Regexp
+	if *sectionName != "" {
+		sectionFilter, err = regexp.Compile(*sectionName)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --section-name: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	var fields map[string]bool
+	if *headerFields != "" {
+		fields, err = parseHeaderFields(*headerFields)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --fields: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *symPattern != "" {
+		opts.symPattern, err = regexp.Compile(*symPattern)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --sym: %v\n", err)
+			os.Exit(1)
+		}
+	}
+	if *addr2section != "" {
+		addr, err := parseHex(*addr2section)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --addr2section address %q\n", *addr2section)
+			os.Exit(1)
+		}
+		opts.lookupAddr = &addr
+	}
+	if *offset2vaddr != "" {
+		offset, err := parseHex(*offset2vaddr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --file-offset-to-vaddr offset %q\n", *offset2vaddr)
+			os.Exit(1)
+		}
+		opts.lookupOffset = &offset
+	}
+	var segment *uint32
+	if *segmentType != "" {
+		t, err := elfreader.ParsePhdrType(*segmentType)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid --segment-type: %v\n", err)
+			os.Exit(1)
+		}
+		segment = &t
+	}
+	if *diff {
+		if flag.NArg() != 2 {
+			fmt.Fprintf(os.Stderr, "Error: --diff takes exactly two files\n")
+			os.Exit(1)
+		}
+		if opts.format != FORMAT_TEXT {
+			fmt.Fprintf(os.Stderr, "Error: --diff only supports --format=text\n")
+			os.Exit(1)
+		}
+	}
+
+	// Every option has been checked by now: nothing may exit between
+	// starting the pager and waiting for it, or the pager would be left
+	// behind with standard error still sent to it. Raw section bytes are
+	// never paged.
+	var pg *pager
+	var output io.Writer = os.Stdout
+	if *usePager && (opts.extract == "" || opts.extractOut != "") {
+		if pg = startPager(); pg != nil {
+			output = pg
+		}
+	}
+	// Output is buffered, and flushed before each error message so that the
+	// two stay in order. Only the last flush reports a failed write, once the
+	// pager is done with.
+	out := bufio.NewWriter(output)
+	p := &Printer{Out: out, Color: color, Palette: palette, Wide: *wide, VerboseSections: *verboseSections, SectionFilter: sectionFilter, SegmentType: segment,
+		SectionSort: *sectionSort, Raw: *raw, Demangle: *demangle, Limit: *limit, HeaderFields: fields}
+	p.Warn = func(msg string) {
+		_ = flushOutput(out)
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", msg)
+	}
+
+	if *diff {
+		err := PrintDiff(p, flag.Arg(0), flag.Arg(1))
+		if err != nil {
+			_ = flushOutput(out)
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+		if ferr := flushOutput(out); ferr != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", ferr)
+			err = ferr
+		}
+		pg.wait()
+		if err != nil {
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Like readelf, name each file only when there is more than one. The
+	// structured formats carry no header, so their documents simply follow
+	// the order of the arguments.
+	failed := false
+	for i, fileName := range flag.Args() {
+		if i > 0 {
+			printSeparator(p, opts.format)
+		}
+		if flag.NArg() > 1 && opts.format == FORMAT_TEXT {
+			p.Printf("File: %s\n", fileName)
+		}
+		if err := dumpFile(p, fileName, &opts); err != nil {
+			_ = flushOutput(out)
+			fmt.Fprintf(os.Stderr, "Error: %s: %v\n", fileName, err)
+			failed = true
+		}
+	}
+	if err := flushOutput(out); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		failed = true
+	}
+	pg.wait()
+	if failed {
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"color-readelf/elfreader"
+)
+
+// update rewrites the golden files with the current output rather than
+// comparing against them: go test -run TestGolden -update
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// FIXTURE is a small static x86-64 executable, built from hello.s with
+// as -o hello.o hello.s && ld -z noseparate-code -z max-page-size=0x1000 -o hello hello.o
+const FIXTURE = "testdata/hello"
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		name  string
+		setup func(p *Printer)
+		print func(p *Printer, f *elfreader.File) error
+	}{
+		{"header", nil, func(p *Printer, f *elfreader.File) error {
+			PrintELFHeader(p, f, f.Ehdr, f.Order)
+			return nil
+		}},
+		{"header-raw", func(p *Printer) { p.Raw = true }, func(p *Printer, f *elfreader.File) error {
+			PrintELFHeader(p, f, f.Ehdr, f.Order)
+			return nil
+		}},
+		{"program-headers", nil, func(p *Printer, f *elfreader.File) error {
+			return PrintProgramHeaders(p, f, f.Ehdr, f.Order)
+		}},
+		{"section-headers", nil, func(p *Printer, f *elfreader.File) error {
+			return PrintSectionHeaders(p, f, f.Ehdr, f.Order)
+		}},
+		{"section-headers-verbose", func(p *Printer) { p.VerboseSections = true }, func(p *Printer, f *elfreader.File) error {
+			return PrintSectionHeaders(p, f, f.Ehdr, f.Order)
+		}},
+	}
+
+	file, err := os.Open(FIXTURE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := elfreader.NewFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var out bytes.Buffer
+			p := &Printer{Out: &out, SectionSort: SORT_INDEX}
+			if tt.setup != nil {
+				tt.setup(p)
+			}
+			if err := tt.print(p, f); err != nil {
+				t.Fatal(err)
+			}
+
+			golden := FIXTURE + "." + tt.name + ".golden"
+			if *update {
+				if err := ioutil.WriteFile(golden, out.Bytes(), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := ioutil.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(out.Bytes(), want) {
+				t.Errorf("output differs from %s:\n%s\nwant:\n%s", golden, out.Bytes(), want)
+			}
+		})
+	}
+}
+
+// printHeader prints the ELF header of the running test binary with the
+// color mode given
+func printHeader(t *testing.T, mode string) string {
+	t.Helper()
+	color, err := colorFromMode(mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	palette, err := loadPalette(COLOR_DEPTH_8, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(exe)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	f, err := elfreader.NewFile(file)
+	if err != nil {
+		t.Skipf("test binary is not ELF: %v", err)
+	}
+
+	var out bytes.Buffer
+	p := &Printer{Out: &out, Color: color, Palette: palette}
+	PrintELFHeader(p, f, f.Ehdr, f.Order)
+	return out.String()
+}
+
+func TestNoColor(t *testing.T) {
+	if out := printHeader(t, "always"); !strings.Contains(out, "\033[") {
+		t.Fatalf("--color=always output has no escape sequences:\n%s", out)
+	}
+
+	t.Setenv("NO_COLOR", "1")
+	if out := printHeader(t, "auto"); strings.Contains(out, "\033") {
+		t.Errorf("output with NO_COLOR set has escape sequences:\n%q", out)
+	}
+}
+
+func TestDumpFileTooSmall(t *testing.T) {
+	name := filepath.Join(t.TempDir(), "tiny")
+	if err := ioutil.WriteFile(name, []byte("\x7fELF\x02\x01\x01\x00\x00\x00"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	var out bytes.Buffer
+	err := dumpFile(&Printer{Out: &out}, name, &options{showHeader: true, format: FORMAT_TEXT})
+	want := "reading ELF header: file too small to be an ELF (10 bytes)"
+	if err == nil || err.Error() != want {
+		t.Errorf("error = %v, want %q", err, want)
+	}
+	if out.Len() != 0 {
+		t.Errorf("printed %q before the error", out.String())
+	}
+}
+
+func TestDumpArchiveMembers(t *testing.T) {
+	hello, err := ioutil.ReadFile(FIXTURE)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive := elfreader.ARCHIVE_MAGIC
+	for _, member := range []struct{ name, contents string }{
+		{"hello.o/", string(hello)},
+		{"README/", "not an object\n"},
+		{"short.o/", elfreader.ELF_MAGIC},
+		{"again.o/", string(hello)},
+	} {
+		archive += fmt.Sprintf("%-16s%-12d%-6d%-6d%-8o%-10d`\n", member.name, 0, 0, 0, 0644, len(member.contents)) + member.contents
+		if len(member.contents)%2 != 0 {
+			archive += "\n"
+		}
+	}
+	name := filepath.Join(t.TempDir(), "lib.a")
+	if err := ioutil.WriteFile(name, []byte(archive), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	var warnings []string
+	p := &Printer{Out: &out, SectionSort: SORT_INDEX, Warn: func(msg string) { warnings = append(warnings, msg) }}
+	err = dumpFile(p, name, &options{showHeader: true, format: FORMAT_TEXT})
+	if err == nil || err.Error() != "1 of 4 archive members could not be read" {
+		t.Errorf("error = %v, want one member to fail", err)
+	}
+
+	for _, member := range []string{"hello.o", "short.o", "again.o"} {
+		if !strings.Contains(out.String(), "File: "+name+"("+member+")\n") {
+			t.Errorf("output does not name member %s:\n%s", member, out.String())
+		}
+	}
+	if n := strings.Count(out.String(), "Entry point address:"); n != 2 {
+		t.Errorf("printed %d headers, want 2", n)
+	}
+	want := []string{
+		name + "(README) is not an ELF object, skipped",
+		name + "(short.o): reading ELF header: file too small to be an ELF (4 bytes)",
+	}
+	if strings.Join(warnings, "\n") != strings.Join(want, "\n") {
+		t.Errorf("warnings = %q, want %q", warnings, want)
+	}
+}
+
+func TestSelectsDump(t *testing.T) {
+	opts := options{format: FORMAT_JSON, offset: 0x1000, forceClass: elfreader.ELFCLASS32, forceEncoding: elfreader.ELFDATA2MSB}
+	if opts.selectsDump() {
+		t.Errorf("options that only change how the file is read select a dump: %+v", opts)
+	}
+	opts.hexDump = ".text"
+	if !opts.selectsDump() {
+		t.Errorf("-x does not select a dump")
+	}
+}
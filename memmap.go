@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintMemoryMap displays where each loadable segment lands in memory,
+// lowest address first, along with the file range it is loaded from
+func PrintMemoryMap(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	segments, err := elfreader.ReadMemoryMap(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(segments) == 0 {
+		p.Printf("There are no loadable segments in this file.\n")
+		return nil
+	}
+
+	width := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		width = 8
+	}
+
+	p.Printf("%s\n", p.colorProgram("Memory map of loadable segments:"))
+	t := newTable("Segment", "Start", "End", "Flg", "File range", "Note")
+	for _, segment := range segments {
+		note := ""
+		if segment.ZeroFill != 0 {
+			note = fmt.Sprintf("0x%x bytes zero-filled", segment.ZeroFill)
+		}
+		t.addRow(
+			fmt.Sprintf("%02d", segment.Index),
+			p.colorAddr("0x%0*x", width, segment.Start),
+			p.colorAddr("0x%0*x", width, segment.End),
+			elfreader.PhdrFlagsString(segment.Flags),
+			p.colorAddr("0x%x-0x%x", segment.FileStart, segment.FileEnd),
+			note,
+		)
+	}
+	t.print(p, "  ")
+	return nil
+}
+
+// PrintAddressLookup reports the sections and segments containing an
+// address, and how far into each it lies
+func PrintAddressLookup(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, addr uint64) error {
+	lookup, err := elfreader.LookupAddress(file, ehdr, order, addr)
+	if err != nil {
+		return err
+	}
+	if len(lookup.Sections) == 0 && len(lookup.Segments) == 0 {
+		p.Printf("Address %s is not mapped.\n", p.colorAddr("0x%x", addr))
+		return nil
+	}
+
+	p.Printf("Address %s is in:\n", p.colorAddr("0x%x", addr))
+	for _, match := range lookup.Sections {
+		p.Printf("  Section [%2d] %s + %s\n", match.Index, p.colorSection(match.Name), p.colorAddr("0x%x", match.Delta))
+	}
+	for _, match := range lookup.Segments {
+		p.Printf("  Segment  %02d  %s + %s\n", match.Index, p.colorProgram(match.Name), p.colorAddr("0x%x", match.Delta))
+	}
+	return nil
+}
+
+// PrintOffsetLookup reports the virtual address a file offset is loaded
+// at, along with the sections and segments holding it
+func PrintOffsetLookup(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, offset uint64) error {
+	lookup, err := elfreader.LookupOffset(file, ehdr, order, offset)
+	if err != nil {
+		return err
+	}
+	if lookup.Mapped {
+		p.Printf("File offset %s is loaded at %s\n", p.colorAddr("0x%x", offset), p.colorAddr("0x%x", lookup.Vaddr))
+	} else {
+		p.Printf("File offset %s is not in any loadable segment.\n", p.colorAddr("0x%x", offset))
+	}
+	for _, match := range lookup.Sections {
+		p.Printf("  Section [%2d] %s + %s\n", match.Index, p.colorSection(match.Name), p.colorAddr("0x%x", match.Delta))
+	}
+	for _, match := range lookup.Segments {
+		p.Printf("  Segment  %02d  %s + %s\n", match.Index, p.colorProgram(match.Name), p.colorAddr("0x%x", match.Delta))
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"color-readelf/elfreader"
+)
+
+// abiTagOSNames maps the OS word of an NT_GNU_ABI_TAG note to its name
+var abiTagOSNames = map[uint32]string{
+	0: "Linux",
+	1: "Hurd",
+	2: "Solaris",
+	3: "FreeBSD",
+}
+
+// noteSummary decodes the descriptor of the notes readelf knows how to explain
+func noteSummary(note elfreader.Note, order binary.ByteOrder) string {
+	if note.Owner != "GNU" {
+		return ""
+	}
+
+	switch note.Type {
+	case elfreader.NT_GNU_BUILD_ID:
+		return fmt.Sprintf("Build ID: %x", note.Desc)
+	case elfreader.NT_GNU_ABI_TAG:
+		if len(note.Desc) < 16 {
+			return ""
+		}
+		osName, ok := abiTagOSNames[order.Uint32(note.Desc)]
+		if !ok {
+			osName = fmt.Sprintf("<unknown: %d>", order.Uint32(note.Desc))
+		}
+		return fmt.Sprintf("OS: %s, ABI: %d.%d.%d", osName,
+			order.Uint32(note.Desc[4:]), order.Uint32(note.Desc[8:]), order.Uint32(note.Desc[12:]))
+	case elfreader.NT_GNU_GOLD_VERSION:
+		return fmt.Sprintf("Version: %s", strings.TrimRight(string(note.Desc), "\x00"))
+	}
+	return ""
+}
+
+// PrintNotes displays the notes of the file like readelf -n
+func PrintNotes(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	groups, err := elfreader.ReadNotes(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(groups) == 0 {
+		p.Printf("There are no notes in this file.\n")
+		return nil
+	}
+
+	for i, group := range groups {
+		if i > 0 {
+			p.Printf("\n")
+		}
+		if group.Name != "" {
+			p.Printf("Displaying notes found in: %s\n", p.colorSection(group.Name))
+		} else {
+			p.Printf("Displaying notes found at file offset %s\n", p.colorAddr("0x%08x", group.Offset))
+		}
+		p.Printf("  Owner                Data size \tDescription\n")
+		for _, note := range group.Notes {
+			p.Printf("  %-20s %s\t%s\n", note.Owner, p.colorAddr("0x%08x", len(note.Desc)), elfreader.NoteTypeName(note.Owner, note.Type))
+			if summary := noteSummary(note, order); summary != "" {
+				p.Printf("    %s\n", summary)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"color-readelf/elfreader"
+	"gopkg.in/yaml.v3"
+)
+
+// Output formats selectable with --format
+const (
+	FORMAT_TEXT         = "text"
+	FORMAT_JSON         = "json"
+	FORMAT_JSON_COMPACT = "json-compact"
+	FORMAT_YAML         = "yaml"
+	FORMAT_CSV          = "csv"
+	FORMAT_XML          = "xml"
+)
+
+// MarshalOutput writes v to w in one of the structured output formats. Field
+// names are the same in every format so the outputs are interchangeable.
+func MarshalOutput(w io.Writer, format string, v interface{}) error {
+	var data []byte
+	var err error
+	if stream, ok := v.(jsonStreamer); ok {
+		if format == FORMAT_JSON || format == FORMAT_JSON_COMPACT {
+			return writeJSON(w, format, stream.writeJSON)
+		}
+		if v, err = stream.collect(); err != nil {
+			return err
+		}
+	}
+	switch format {
+	case FORMAT_JSON, FORMAT_JSON_COMPACT:
+		// Lists are written an entry at a time
+		if list := reflect.ValueOf(v); list.Kind() == reflect.Slice {
+			return writeJSON(w, format, func(w io.Writer, compact bool) error {
+				a := newJSONArray(w, "", compact)
+				for i := 0; i < list.Len(); i++ {
+					a.add(list.Index(i).Interface())
+				}
+				return a.close()
+			})
+		}
+		if format == FORMAT_JSON {
+			data, err = json.MarshalIndent(v, "", "  ")
+		} else {
+			data, err = json.Marshal(v)
+		}
+		data = append(data, '\n')
+	case FORMAT_YAML:
+		data, err = yaml.Marshal(v)
+	case FORMAT_CSV:
+		return writeCSV(w, v)
+	case FORMAT_XML:
+		return writeXML(w, v)
+	default:
+		return fmt.Errorf("unsupported output format %q", format)
+	}
+	if err != nil {
+		return fmt.Errorf("converting to %s: %w", format, err)
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// jsonStreamer is implemented by dump data that is written as JSON while
+// it is being read, rather than collected first, so that memory use stays
+// flat however many entries there are. collect gathers the whole value for
+// the other formats.
+type jsonStreamer interface {
+	writeJSON(w io.Writer, compact bool) error
+	collect() (interface{}, error)
+}
+
+// writeJSON runs a streaming writer for one JSON document
+func writeJSON(w io.Writer, format string, write func(w io.Writer, compact bool) error) error {
+	if err := write(w, format == FORMAT_JSON_COMPACT); err != nil {
+		return fmt.Errorf("converting to %s: %w", format, err)
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// jsonArray writes a JSON array an element at a time. The layout is the
+// one json.MarshalIndent, or json.Marshal when compact, gives the whole
+// array when it starts at the given indentation. Errors are kept until
+// close.
+type jsonArray struct {
+	w       io.Writer
+	indent  string
+	compact bool
+	n       int
+	err     error
+}
+
+func newJSONArray(w io.Writer, indent string, compact bool) *jsonArray {
+	a := &jsonArray{w: w, indent: indent, compact: compact}
+	_, a.err = io.WriteString(w, "[")
+	return a
+}
+
+// next starts a new element and returns its indentation
+func (a *jsonArray) next() string {
+	sep := ","
+	if a.n == 0 {
+		sep = ""
+	}
+	a.n++
+	if !a.compact {
+		sep += "\n" + a.indent + "  "
+	}
+	a.write(sep)
+	return a.indent + "  "
+}
+
+// write writes part of an element started with next
+func (a *jsonArray) write(s string) {
+	if a.err == nil {
+		_, a.err = io.WriteString(a.w, s)
+	}
+}
+
+// add writes v as the next element
+func (a *jsonArray) add(v interface{}) {
+	indent := a.next()
+	if a.err != nil {
+		return
+	}
+	var data []byte
+	if a.compact {
+		data, a.err = json.Marshal(v)
+	} else {
+		data, a.err = json.MarshalIndent(v, indent, "  ")
+	}
+	a.write(string(data))
+}
+
+// close ends the array and returns the first error met writing it
+func (a *jsonArray) close() error {
+	if a.n > 0 && !a.compact {
+		a.write("\n" + a.indent)
+	}
+	a.write("]")
+	return a.err
+}
+
+// xmlNames returns the root element name for v and, when v is a list, the
+// name of the element holding each entry
+func xmlNames(v interface{}) (string, string) {
+	switch v.(type) {
+	case *elfreader.Elf64Ehdr:
+		return "ElfHeader", ""
+	case []elfreader.Elf64Phdr:
+		return "ProgramHeaders", "Segment"
+	case []elfreader.Elf64ShdrWithName:
+		return "SectionHeaders", "Section"
+	}
+	return "Elf", "Entry"
+}
+
+// writeXML writes v as an indented XML document. Lists are wrapped in a
+// root element, as XML has no bare sequence of elements.
+func writeXML(w io.Writer, v interface{}) error {
+	root, item := xmlNames(v)
+	e := xml.NewEncoder(w)
+	e.Indent("", "  ")
+
+	var err error
+	list := reflect.ValueOf(v)
+	if list.Kind() == reflect.Slice {
+		start := xml.StartElement{Name: xml.Name{Local: root}}
+		err = e.EncodeToken(start)
+		for i := 0; i < list.Len() && err == nil; i++ {
+			err = e.EncodeElement(list.Index(i).Interface(), xml.StartElement{Name: xml.Name{Local: item}})
+		}
+		if err == nil {
+			err = e.EncodeToken(start.End())
+		}
+	} else {
+		err = e.EncodeElement(v, xml.StartElement{Name: xml.Name{Local: root}})
+	}
+	if err == nil {
+		err = e.Flush()
+	}
+	if err != nil {
+		return fmt.Errorf("converting to xml: %w", err)
+	}
+	_, err = io.WriteString(w, "\n")
+	return err
+}
+
+// writeCSV writes section or program headers as CSV rows under a header line
+func writeCSV(w io.Writer, v interface{}) error {
+	var rows [][]string
+	switch headers := v.(type) {
+	case []elfreader.Elf64ShdrWithName:
+		rows = append(rows, []string{"Index", "Name", "Type", "Flags", "Addr", "Offset", "Size", "Link", "Info", "Addralign", "Entsize"})
+		for _, shdr := range headers {
+			rows = append(rows, []string{
+				strconv.Itoa(shdr.Index),
+				shdr.Name,
+				elfreader.SectionTypeName(shdr.Type),
+				elfreader.SectionFlagsString(shdr.Flags),
+				csvHex(shdr.Addr),
+				csvHex(shdr.Offset),
+				strconv.FormatUint(shdr.Size, 10),
+				strconv.FormatUint(uint64(shdr.Link), 10),
+				strconv.FormatUint(uint64(shdr.Info), 10),
+				strconv.FormatUint(shdr.Addralign, 10),
+				strconv.FormatUint(shdr.Entsize, 10),
+			})
+		}
+	case []elfreader.Elf64Phdr:
+		rows = append(rows, []string{"Type", "Offset", "Vaddr", "Paddr", "Filesz", "Memsz", "Flags", "Align"})
+		for _, phdr := range headers {
+			rows = append(rows, []string{
+				elfreader.PhdrTypeName(phdr.Type),
+				csvHex(phdr.Offset),
+				csvHex(phdr.Vaddr),
+				csvHex(phdr.Paddr),
+				strconv.FormatUint(phdr.Filesz, 10),
+				strconv.FormatUint(phdr.Memsz, 10),
+				strings.TrimSpace(elfreader.PhdrFlagsString(phdr.Flags)),
+				strconv.FormatUint(phdr.Align, 10),
+			})
+		}
+	default:
+		return fmt.Errorf("csv output is only available for section and program headers")
+	}
+
+	if err := csv.NewWriter(w).WriteAll(rows); err != nil {
+		return fmt.Errorf("writing csv: %w", err)
+	}
+	return nil
+}
+
+// csvHex formats an address or offset the way the text output shows it
+func csvHex(v uint64) string {
+	return "0x" + strconv.FormatUint(v, 16)
+}
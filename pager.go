@@ -0,0 +1,80 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// DEFAULT_PAGER is the pager used when $PAGER is not set. -R lets the color
+// escape sequences through.
+const DEFAULT_PAGER = "less -R"
+
+// pager is a pager program reading the output through a pipe
+type pager struct {
+	cmd    *exec.Cmd
+	pipe   *os.File
+	stderr *os.File
+}
+
+// startPager starts the pager named by $PAGER, or DEFAULT_PAGER, when
+// standard output is a terminal. Standard error is sent through the pager
+// too when it is a terminal, so that errors show up among the output
+// rather than under it. It returns nil, leaving the output as it is, when
+// the output is redirected or the pager cannot be started.
+func startPager() *pager {
+	if !isTerminal(os.Stdout) {
+		return nil
+	}
+	args := strings.Fields(os.Getenv("PAGER"))
+	if len(args) == 0 {
+		args = strings.Fields(DEFAULT_PAGER)
+	}
+	// "cat" as a pager means no paging
+	if args[0] == "cat" {
+		return nil
+	}
+
+	r, w, err := os.Pipe()
+	if err != nil {
+		return nil
+	}
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stdin = r
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	// Plain less still shows colors
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(os.Environ(), "LESS=R")
+	}
+	if err := cmd.Start(); err != nil {
+		r.Close()
+		w.Close()
+		return nil
+	}
+	r.Close()
+
+	pg := &pager{cmd: cmd, pipe: w, stderr: os.Stderr}
+	if isTerminal(os.Stderr) {
+		os.Stderr = w
+	}
+	return pg
+}
+
+// Write passes output to the pager. Once the user quits the pager the rest
+// of the output is dropped rather than reported as an error.
+func (pg *pager) Write(b []byte) (int, error) {
+	pg.pipe.Write(b)
+	return len(b), nil
+}
+
+// wait closes the pipe and waits for the user to quit the pager. It does
+// nothing when no pager was started.
+func (pg *pager) wait() {
+	if pg == nil {
+		return
+	}
+	os.Stderr = pg.stderr
+	pg.pipe.Close()
+	pg.cmd.Wait()
+}
@@ -0,0 +1,210 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Color categories, the keys of a palette
+const (
+	CATEGORY_SECTION = "section"
+	CATEGORY_PROGRAM = "program"
+	CATEGORY_ADDRESS = "address"
+	CATEGORY_ADDED   = "added"
+	CATEGORY_REMOVED = "removed"
+	CATEGORY_ZERO    = "zero"
+)
+
+// COLORS_ENV names the environment variable holding a color map, in the
+// same key=value form as --color-map with entries separated by commas
+const COLORS_ENV = "COLOR_READELF_COLORS"
+
+// Color depths selectable with --color-depth
+const (
+	COLOR_DEPTH_AUTO      = "auto"
+	COLOR_DEPTH_8         = "8"
+	COLOR_DEPTH_256       = "256"
+	COLOR_DEPTH_TRUECOLOR = "truecolor"
+)
+
+// colorNames maps the named colors to their SGR parameters
+var colorNames = map[string]string{
+	"black":   "0;30",
+	"red":     "0;31",
+	"green":   "0;32",
+	"yellow":  "0;33",
+	"blue":    "0;34",
+	"magenta": "0;35",
+	"cyan":    "0;36",
+	"white":   "0;37",
+	"bold":    "1",
+	"dim":     "2",
+	"none":    "",
+}
+
+// detectColorDepth guesses what the terminal supports from COLORTERM, and
+// from TERM for 256 colors, settling for the basic 8 colors when unsure
+func detectColorDepth() string {
+	switch strings.ToLower(os.Getenv("COLORTERM")) {
+	case "truecolor", "24bit":
+		return COLOR_DEPTH_TRUECOLOR
+	}
+	if strings.Contains(os.Getenv("TERM"), "256color") {
+		return COLOR_DEPTH_256
+	}
+	return COLOR_DEPTH_8
+}
+
+// colorDepthFromMode resolves a --color-depth value
+func colorDepthFromMode(mode string) (string, error) {
+	switch mode {
+	case COLOR_DEPTH_AUTO:
+		return detectColorDepth(), nil
+	case COLOR_DEPTH_8, COLOR_DEPTH_256, COLOR_DEPTH_TRUECOLOR:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid --color-depth value %q (want auto, 8, 256 or truecolor)", mode)
+}
+
+// defaultPalette returns the escape sequence used for each category unless
+// it is overridden. Deeper terminals get softer hues of the same colors.
+func defaultPalette(depth string) map[string]string {
+	switch depth {
+	case COLOR_DEPTH_256:
+		return map[string]string{
+			CATEGORY_SECTION: "\033[38;5;75m",
+			CATEGORY_PROGRAM: "\033[38;5;114m",
+			CATEGORY_ADDRESS: "\033[38;5;176m",
+			CATEGORY_ADDED:   "\033[38;5;114m",
+			CATEGORY_REMOVED: "\033[38;5;203m",
+			CATEGORY_ZERO:    DIM_TEXT,
+		}
+	case COLOR_DEPTH_TRUECOLOR:
+		return map[string]string{
+			CATEGORY_SECTION: "\033[38;2;95;175;255m",
+			CATEGORY_PROGRAM: "\033[38;2;135;215;135m",
+			CATEGORY_ADDRESS: "\033[38;2;215;135;215m",
+			CATEGORY_ADDED:   "\033[38;2;135;215;135m",
+			CATEGORY_REMOVED: "\033[38;2;255;95;95m",
+			CATEGORY_ZERO:    DIM_TEXT,
+		}
+	}
+	return map[string]string{
+		CATEGORY_SECTION: BLUE_TEXT,
+		CATEGORY_PROGRAM: GREEN_TEXT,
+		CATEGORY_ADDRESS: MAGENTA_TEXT,
+		CATEGORY_ADDED:   GREEN_TEXT,
+		CATEGORY_REMOVED: RED_TEXT,
+		CATEGORY_ZERO:    DIM_TEXT,
+	}
+}
+
+// parseRGB turns a "#rrggbb" color into truecolor SGR parameters
+func parseRGB(value string) (string, bool) {
+	if len(value) != 7 || value[0] != '#' {
+		return "", false
+	}
+	rgb, err := strconv.ParseUint(value[1:], 16, 32)
+	if err != nil {
+		return "", false
+	}
+	return fmt.Sprintf("38;2;%d;%d;%d", rgb>>16, rgb>>8&0xff, rgb&0xff), true
+}
+
+// isSGR reports whether s is a list of raw SGR parameters such as "1;33"
+func isSGR(s string) bool {
+	for _, field := range strings.Split(s, ";") {
+		if field == "" {
+			return false
+		}
+		for _, c := range field {
+			if c < '0' || c > '9' {
+				return false
+			}
+		}
+	}
+	return s != ""
+}
+
+// parseColor turns a color name, a "#rrggbb" truecolor value or raw SGR
+// parameters such as "38;5;208" into an escape sequence. "none" leaves the
+// category uncolored.
+func parseColor(value string) (string, error) {
+	sgr, ok := colorNames[strings.ToLower(value)]
+	if !ok {
+		sgr, ok = parseRGB(value)
+	}
+	if !ok {
+		if !isSGR(value) {
+			names := make([]string, 0, len(colorNames))
+			for name := range colorNames {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			return "", fmt.Errorf("unknown color %q (want one of %s, #rrggbb, or ANSI codes such as 1;33)", value, strings.Join(names, ", "))
+		}
+		sgr = value
+	}
+	if sgr == "" {
+		return "", nil
+	}
+	return "\033[" + sgr + "m", nil
+}
+
+// applyColorMap sets the colors named by a comma-separated list of
+// category=color entries in palette
+func applyColorMap(palette map[string]string, spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		eq := strings.IndexByte(entry, '=')
+		if eq < 0 {
+			return fmt.Errorf("invalid color map entry %q (want category=color)", entry)
+		}
+		category, value := strings.TrimSpace(entry[:eq]), strings.TrimSpace(entry[eq+1:])
+		if _, ok := palette[category]; !ok {
+			return fmt.Errorf("unknown color category %q (want %s, %s, %s, %s, %s or %s)",
+				category, CATEGORY_SECTION, CATEGORY_PROGRAM, CATEGORY_ADDRESS, CATEGORY_ADDED, CATEGORY_REMOVED, CATEGORY_ZERO)
+		}
+		color, err := parseColor(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", category, err)
+		}
+		palette[category] = color
+	}
+	return nil
+}
+
+// colorMapFlag collects the repeated --color-map flags
+type colorMapFlag []string
+
+func (f *colorMapFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *colorMapFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// loadPalette builds the palette from the defaults for the color depth,
+// then the environment, then the --color-map flags, each overriding the one
+// before
+func loadPalette(depth string, colorMaps []string) (map[string]string, error) {
+	palette := defaultPalette(depth)
+	if spec := os.Getenv(COLORS_ENV); spec != "" {
+		if err := applyColorMap(palette, spec); err != nil {
+			return nil, fmt.Errorf("%s: %w", COLORS_ENV, err)
+		}
+	}
+	for _, spec := range colorMaps {
+		if err := applyColorMap(palette, spec); err != nil {
+			return nil, fmt.Errorf("--color-map: %w", err)
+		}
+	}
+	return palette, nil
+}
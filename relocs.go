@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintRelocations displays the entries of every SHT_RELA and SHT_REL section
+func PrintRelocations(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	tables, err := elfreader.ReadRelocations(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		p.Printf("There are no relocations in this file.\n")
+		return nil
+	}
+
+	width := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		width = 8
+	}
+
+	for _, table := range tables {
+		p.Printf("Relocation section '%s' at offset %s contains %d entries:\n", p.colorSection(table.Section), p.colorAddr("0x%x", table.Offset), len(table.Entries))
+		symHeader := "Sym. Name"
+		if table.Rela {
+			symHeader += " + Addend"
+		}
+		p.Printf("  %-*s %-*s %-24s %-*s %s\n", width, "Offset", width, "Info", "Type", width, "Sym. Value", symHeader)
+		shown := p.limited(len(table.Entries))
+		for _, entry := range table.Entries[:shown] {
+			line := fmt.Sprintf("  %s %0*x %-24s %s %s", p.colorAddr("%0*x", width, entry.Offset), width, entry.Info,
+				elfreader.RelocTypeName(ehdr.Machine, entry.Type), p.colorAddr("%0*x", width, entry.SymValue), p.symbolName(entry.SymName))
+			if table.Rela {
+				if entry.Addend < 0 {
+					line += fmt.Sprintf(" - %x", -entry.Addend)
+				} else {
+					line += fmt.Sprintf(" + %x", entry.Addend)
+				}
+			}
+			p.Printf("%s\n", line)
+		}
+		p.printOmitted(len(table.Entries)-shown, "relocations")
+		p.Printf("\n")
+	}
+	return nil
+}
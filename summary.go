@@ -0,0 +1,70 @@
+package main
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"color-readelf/elfreader"
+)
+
+// PrintSummary displays a one-screen overview of the file
+func PrintSummary(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	s, err := elfreader.ReadSummary(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+
+	fileSize := "unknown"
+	if s.FileSize >= 0 {
+		fileSize = fmt.Sprintf("%d bytes", s.FileSize)
+	}
+	largest := "(none)"
+	if s.LargestSection != "" {
+		largest = fmt.Sprintf("%s (%d bytes)", p.colorSection(s.LargestSection), s.LargestSize)
+	}
+
+	t := newTable("Property", "Value")
+	t.addRow("Type", s.Type)
+	t.addRow("Machine", s.Machine)
+	t.addRow("File size", fileSize)
+	t.addRow(p.colorProgram("Program headers"), fmt.Sprintf("%d", s.ProgramHeaders))
+	t.addRow(p.colorSection("Section headers"), fmt.Sprintf("%d", s.SectionHeaders))
+	t.addRow("Loaded memory size", fmt.Sprintf("%d bytes", s.LoadMemSize))
+	t.addRow("Largest section", largest)
+	t.addRow("Dynamic", yesNo(s.Dynamic))
+	t.addRow("PIE", yesNo(s.PIE))
+	t.addRow("Stripped", yesNo(s.Stripped))
+	t.print(p, "")
+	return nil
+}
+
+// PrintAddresses displays the entry point, load base and program header
+// address as key: value lines for scripts. Addresses the file does not
+// have are left out.
+func PrintAddresses(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	addrs, err := elfreader.ReadAddresses(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+
+	p.Printf("entry: %s\n", p.colorAddr("0x%x", addrs.Entry))
+	if addrs.HasBase {
+		p.Printf("base: %s\n", p.colorAddr("0x%x", addrs.Base))
+	}
+	if addrs.HasPhdr {
+		p.Printf("phdr: %s\n", p.colorAddr("0x%x", addrs.Phdr))
+	}
+	return nil
+}
+
+// PrintCounts prints the number of program headers, section headers,
+// symbols and relocations as key=value pairs on one line
+func PrintCounts(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	c, err := elfreader.ReadCounts(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	p.Printf("phnum=%d shnum=%d symbols=%d relocs=%d\n", c.ProgramHeaders, c.SectionHeaders, c.Symbols, c.Relocations)
+	return nil
+}
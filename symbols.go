@@ -0,0 +1,203 @@
+package main
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+
+	"color-readelf/elfreader"
+)
+
+// symbolNames returns the type, binding and visibility of a symbol as
+// readelf names them, or as numbers in --raw mode
+func symbolNames(p *Printer, sym elfreader.Elf64SymWithName) (string, string, string) {
+	symType := elfreader.SymType(sym.Info)
+	bind := elfreader.SymBind(sym.Info)
+	vis := elfreader.SymVisibility(sym.Other)
+	return p.decoded(elfreader.SymTypeName(symType), fmt.Sprint(symType)),
+		p.decoded(elfreader.SymBindName(bind), fmt.Sprint(bind)),
+		p.decoded(elfreader.SymVisibilityName(vis), fmt.Sprint(vis))
+}
+
+// symbolSection returns the section a symbol is defined in: UND, ABS, COM
+// or its index, followed in wide mode by the name of an ordinary section
+func symbolSection(p *Printer, sym elfreader.Elf64SymWithName, shdrwns []elfreader.Elf64ShdrWithName) string {
+	ndx := p.decoded(elfreader.SectionIndexName(sym.Shndx), fmt.Sprint(sym.Shndx))
+	if p.Wide && !p.Raw && sym.Shndx != elfreader.SHN_UNDEF && sym.Shndx < elfreader.SHN_LORESERVE && int(sym.Shndx) < len(shdrwns) {
+		ndx += " (" + p.colorSection(shdrwns[sym.Shndx].Name) + ")"
+	}
+	return ndx
+}
+
+// PrintSymbols displays the entries of every symbol table in the file
+func PrintSymbols(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	tables, err := elfreader.ReadSymbolTables(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if len(tables) == 0 {
+		p.Printf("There are no symbol tables in this file.\n")
+		return nil
+	}
+
+	var shdrwns []elfreader.Elf64ShdrWithName
+	if p.Wide {
+		shdrwns, err = elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	valueWidth := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		valueWidth = 8
+	}
+
+	for _, table := range tables {
+		p.Printf("Symbol table '%s' contains %d entries:\n", p.colorSection(table.Section), len(table.Symbols))
+		shown := p.limited(len(table.Symbols))
+		if p.Wide {
+			t := newTable("Num:", "Value", "Size", "Type", "Bind", "Vis", "Ndx", "Name")
+			t.alignRight(0, 2)
+			for j, sym := range table.Symbols[:shown] {
+				symType, bind, vis := symbolNames(p, sym)
+				t.addRow(
+					fmt.Sprintf("%d:", j),
+					p.colorAddr("%0*x", valueWidth, sym.Value),
+					p.colorSize("%d", sym.Size),
+					symType,
+					bind,
+					vis,
+					symbolSection(p, sym, shdrwns),
+					p.symbolName(sym.Name),
+				)
+			}
+			t.print(p, "  ")
+			p.printOmitted(len(table.Symbols)-shown, "symbols")
+			p.Printf("\n")
+			continue
+		}
+		p.Printf("   Num: %-*s  Size Type    Bind   Vis      Ndx Name\n", valueWidth, "Value")
+		for j, sym := range table.Symbols[:shown] {
+			symType, bind, vis := symbolNames(p, sym)
+			p.Printf("%6d: %s %s %-7s %-6s %-8s %3s %s\n",
+				j, p.colorAddr("%0*x", valueWidth, sym.Value), p.colorSize("%5d", sym.Size), symType, bind, vis, symbolSection(p, sym, shdrwns), p.symbolName(sym.Name))
+		}
+		p.printOmitted(len(table.Symbols)-shown, "symbols")
+		p.Printf("\n")
+	}
+	return nil
+}
+
+// PrintSymbolSearch lists the symbols of every symbol table whose name
+// matches re. Finding none is an error, so that scripts can test for a
+// symbol through the exit status.
+func PrintSymbolSearch(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, re *regexp.Regexp) error {
+	matches, err := findSymbols(file, ehdr, order, re)
+	if err != nil {
+		return err
+	}
+
+	var shdrwns []elfreader.Elf64ShdrWithName
+	if p.Wide {
+		shdrwns, err = elfreader.MakeSectionHeaderWithName(file, ehdr, order)
+		if err != nil {
+			return err
+		}
+	}
+
+	valueWidth := 16
+	if ehdr.Ident[elfreader.EI_CLASS] == elfreader.ELFCLASS32 {
+		valueWidth = 8
+	}
+
+	t := newTable("Table", "Num:", "Value", "Size", "Type", "Bind", "Vis", "Ndx", "Name")
+	t.alignRight(1, 3)
+	for _, match := range matches[:p.limited(len(matches))] {
+		sym := match.Symbol
+		symType, bind, vis := symbolNames(p, sym)
+		t.addRow(
+			p.colorSection(match.Table),
+			fmt.Sprintf("%d:", match.Index),
+			p.colorAddr("%0*x", valueWidth, sym.Value),
+			p.colorSize("%d", sym.Size),
+			symType,
+			bind,
+			vis,
+			symbolSection(p, sym, shdrwns),
+			p.symbolName(sym.Name),
+		)
+	}
+	t.print(p, "")
+	p.printOmitted(len(matches)-p.limited(len(matches)), "matches")
+	return nil
+}
+
+// findSymbols wraps elfreader.FindSymbols, failing when nothing matches
+func findSymbols(file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder, re *regexp.Regexp) ([]elfreader.SymbolMatch, error) {
+	matches, err := elfreader.FindSymbols(file, ehdr, order, re)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, fmt.Errorf("no symbol matches %q", re.String())
+	}
+	return matches, nil
+}
+
+// symbolTables is the data of the symbol table dump, which is streamed
+// in JSON as the symbols are read
+type symbolTables struct {
+	file  io.ReaderAt
+	ehdr  *elfreader.Elf64Ehdr
+	order binary.ByteOrder
+}
+
+func (t symbolTables) collect() (interface{}, error) {
+	return elfreader.ReadSymbolTables(t.file, t.ehdr, t.order)
+}
+
+// writeJSON writes the tables in the layout of the collected []SymbolTable
+func (t symbolTables) writeJSON(w io.Writer, compact bool) error {
+	shdrwns, err := elfreader.MakeSectionHeaderWithName(t.file, t.ehdr, t.order)
+	if err != nil {
+		return err
+	}
+
+	nl, colon := "", ":"
+	tables := newJSONArray(w, "", compact)
+	for i := range shdrwns {
+		if shdrwns[i].Type != elfreader.SHT_SYMTAB && shdrwns[i].Type != elfreader.SHT_DYNSYM {
+			continue
+		}
+		indent := tables.next()
+		if !compact {
+			nl, colon = "\n"+indent+"  ", ": "
+		}
+		name, err := json.Marshal(shdrwns[i].Name)
+		if err != nil {
+			return err
+		}
+		tables.write("{" + nl + `"Section"` + colon + string(name) + "," + nl + `"Symbols"` + colon)
+
+		symbols := newJSONArray(w, indent+"  ", compact)
+		err = elfreader.WalkSymbols(t.file, t.ehdr, t.order, shdrwns, i, func(j int, symwn elfreader.Elf64SymWithName) error {
+			symbols.add(symwn)
+			return symbols.err
+		})
+		if err == nil {
+			err = symbols.close()
+		}
+		if err != nil {
+			return err
+		}
+		if compact {
+			tables.write("}")
+		} else {
+			tables.write("\n" + indent + "}")
+		}
+	}
+	return tables.close()
+}
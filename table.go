@@ -0,0 +1,82 @@
+package main
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// cellWidth returns the number of characters a cell takes on screen, not
+// counting the escape sequences of any color it carries
+func cellWidth(cell string) int {
+	width := 0
+	for i := 0; i < len(cell); {
+		if cell[i] == '\033' {
+			for i < len(cell) && cell[i] != 'm' {
+				i++
+			}
+			i++
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(cell[i:])
+		i += size
+		width++
+	}
+	return width
+}
+
+// table lays out rows of cells in columns sized to their widest cell. The
+// widths are computed over every row before anything is printed. Cells may
+// already be colored.
+type table struct {
+	header []string
+	rows   [][]string
+	// right marks the columns whose cells are aligned to the right
+	right []bool
+}
+
+// newTable returns a table with the given column headings
+func newTable(header ...string) *table {
+	return &table{header: header, right: make([]bool, len(header))}
+}
+
+// alignRight aligns the cells of the given columns to the right
+func (t *table) alignRight(columns ...int) {
+	for _, c := range columns {
+		t.right[c] = true
+	}
+}
+
+// addRow appends one row of cells
+func (t *table) addRow(cells ...string) {
+	t.rows = append(t.rows, cells)
+}
+
+// print writes the header and every row, each prefixed by indent. Lines
+// carry no trailing spaces, even when their last cells are empty.
+func (t *table) print(p *Printer, indent string) {
+	widths := make([]int, len(t.header))
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		for c, cell := range row {
+			if n := cellWidth(cell); n > widths[c] {
+				widths[c] = n
+			}
+		}
+	}
+
+	for _, row := range append([][]string{t.header}, t.rows...) {
+		var line strings.Builder
+		line.WriteString(indent)
+		for c, cell := range row {
+			if c > 0 {
+				line.WriteString(" ")
+			}
+			pad := strings.Repeat(" ", widths[c]-cellWidth(cell))
+			if t.right[c] {
+				line.WriteString(pad + cell)
+			} else {
+				line.WriteString(cell + pad)
+			}
+		}
+		p.Printf("%s\n", strings.TrimRight(line.String(), " "))
+	}
+}
@@ -0,0 +1,22 @@
+	.globl	_start
+	.text
+_start:
+	mov	$1, %eax
+	mov	$1, %edi
+	lea	msg(%rip), %rsi
+	mov	$len, %edx
+	syscall
+	mov	$60, %eax
+	xor	%edi, %edi
+	syscall
+
+	.data
+msg:
+	.ascii	"hello\n"
+	.set	len, . - msg
+
+	.bss
+buf:
+	.zero	16
+
+	.section .note.GNU-stack,"",@progbits
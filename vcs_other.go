@@ -0,0 +1,10 @@
+//go:build !go1.18
+// +build !go1.18
+
+package main
+
+// vcsRevision returns "" because go versions before 1.18 do not record VCS
+// information in the binary
+func vcsRevision() string {
+	return ""
+}
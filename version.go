@@ -0,0 +1,20 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+)
+
+// version is replaced at build time with -ldflags "-X main.version=..."
+var version = "dev"
+
+// printVersion writes the program version, the Go version it was built with
+// and, when the build recorded it, the VCS revision
+func printVersion(w io.Writer) {
+	fmt.Fprintf(w, "color-readelf %s\n", version)
+	fmt.Fprintf(w, "  Go version: %s\n", runtime.Version())
+	if revision := vcsRevision(); revision != "" {
+		fmt.Fprintf(w, "  Revision:   %s\n", revision)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"encoding/binary"
+	"io"
+	"strings"
+
+	"color-readelf/elfreader"
+)
+
+// versionFlagsString renders vd_flags and vna_flags like readelf
+func versionFlagsString(flags uint16) string {
+	var names []string
+	if flags&elfreader.VER_FLG_BASE != 0 {
+		names = append(names, "BASE")
+	}
+	if flags&elfreader.VER_FLG_WEAK != 0 {
+		names = append(names, "WEAK")
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, " | ")
+}
+
+// PrintVersionInfo displays the version bound to each dynamic symbol along
+// with the version definitions and requirements, like readelf -V
+func PrintVersionInfo(p *Printer, file io.ReaderAt, ehdr *elfreader.Elf64Ehdr, order binary.ByteOrder) error {
+	info, err := elfreader.ReadVersionInfo(file, ehdr, order)
+	if err != nil {
+		return err
+	}
+	if info == nil {
+		p.Printf("No version information found in this file.\n")
+		return nil
+	}
+
+	// Separate the parts that are present with a blank line
+	printed := false
+	separate := func() {
+		if printed {
+			p.Printf("\n")
+		}
+		printed = true
+	}
+
+	if len(info.Symbols) > 0 {
+		separate()
+		p.Printf("Version symbols section contains %d entries:\n", len(info.Symbols))
+		p.Printf("   Num: Ndx  Version              Name\n")
+		for i, sv := range info.Symbols {
+			version := sv.Version
+			if sv.Hidden {
+				version += " (hidden)"
+			}
+			p.Printf("%6d: %4d %-20s %s\n", i, sv.Index, version, p.symbolName(sv.Symbol))
+		}
+	}
+
+	if len(info.Definitions) > 0 {
+		separate()
+		p.Printf("Version definitions contain %d entries:\n", len(info.Definitions))
+		for _, def := range info.Definitions {
+			name := ""
+			if len(def.Names) > 0 {
+				name = def.Names[0]
+			}
+			p.Printf("  Index: %d  Flags: %s  Name: %s\n", def.Index, versionFlagsString(def.Flags), name)
+			for j := 1; j < len(def.Names); j++ {
+				p.Printf("    Parent %d: %s\n", j, def.Names[j])
+			}
+		}
+	}
+
+	if len(info.Requirements) > 0 {
+		separate()
+		p.Printf("Version needs contain %d entries:\n", len(info.Requirements))
+		for _, req := range info.Requirements {
+			p.Printf("  File: %s  Cnt: %d\n", req.File, len(req.Versions))
+			for _, need := range req.Versions {
+				p.Printf("    Name: %-20s Flags: %s  Version: %d\n", need.Name, versionFlagsString(need.Flags), need.Index)
+			}
+		}
+	}
+	return nil
+}